@@ -0,0 +1,94 @@
+package goodie
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// statusWriter records the status and number of bytes written for the access log
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush keeps streaming responses such as TailFile working
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying ResponseWriter
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// accessEntry is filled in while the request is served, then logged.
+// It is locked, as a handler may still be running after Timeout has answered the request
+type accessEntry struct {
+	mu      sync.Mutex
+	handler string // app and handler type, if a page was matched
+	route   string // registered page pattern, if a page was matched
+}
+
+type accessKey struct{}
+
+// setAccessHandler will record the name of the handler and the route serving req in its access log entry
+func setAccessHandler(req *http.Request, name string, route string) {
+	if entry, ok := req.Context().Value(accessKey{}).(*accessEntry); ok {
+		entry.mu.Lock()
+		entry.handler = name
+		entry.route = route
+		entry.mu.Unlock()
+	}
+}
+
+// logAccess will serve the request with h, then log the method, path, status, bytes, duration and handler,
+// and record it in the metrics if they are enabled
+func (s *Server) logAccess(h http.Handler, w http.ResponseWriter, req *http.Request) {
+	if s.metrics != nil {
+		atomic.AddInt64(&s.metrics.inFlight, 1)
+		defer atomic.AddInt64(&s.metrics.inFlight, -1)
+	}
+	start := time.Now()
+	entry := &accessEntry{}
+	sw := &statusWriter{ResponseWriter: w}
+	req = req.WithContext(context.WithValue(req.Context(), accessKey{}, entry))
+
+	h.ServeHTTP(sw, req)
+
+	status := sw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	entry.mu.Lock()
+	handler, route := entry.handler, entry.route
+	entry.mu.Unlock()
+	if s.metrics != nil {
+		s.metrics.observe(req.Method, route, status, time.Since(start))
+	}
+	if len(handler) == 0 {
+		handler = "-"
+	}
+	s.log().Infof("%s %s %s %d %d %s %s", req.RemoteAddr, req.Method, req.URL.RequestURI(), status, sw.bytes, time.Since(start), handler)
+}
@@ -0,0 +1,65 @@
+package goodie
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+)
+
+// BasicAuth is middleware that requires HTTP basic authentication for every request, check is called with the
+// user and password.  Requests that fail get a 401 Unauthorized and never reach the handlers.
+// To protect only one App, use App.BasicAuth
+func BasicAuth(realm string, check func(user string, pass string) bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !basicAuthorized(req, check) {
+				unauthorized(w, realm)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// BasicAuth will require HTTP basic authentication for the pages of the App
+func (a *App) BasicAuth(realm string, check func(user string, pass string) bool) {
+	a.Use(basicAuth(realm, check))
+}
+
+// basicAuth is odie middleware requiring HTTP basic authentication
+func basicAuth(realm string, check func(user string, pass string) bool) OdieMiddleware {
+	return func(next OdieHandler) OdieHandler {
+		return func(odie *Odie) {
+			if !basicAuthorized(odie.Request, check) {
+				unauthorized(odie.Response, realm)
+				odie.committed = true
+				return
+			}
+			next(odie)
+		}
+	}
+}
+
+// StaticCredentials returns a check for BasicAuth accepting only user and pass, compared in constant time
+func StaticCredentials(user string, pass string) func(string, string) bool {
+	wantUser := sha256.Sum256([]byte(user))
+	wantPass := sha256.Sum256([]byte(pass))
+	return func(u string, p string) bool {
+		gotUser := sha256.Sum256([]byte(u))
+		gotPass := sha256.Sum256([]byte(p))
+		userOk := subtle.ConstantTimeCompare(gotUser[:], wantUser[:])
+		passOk := subtle.ConstantTimeCompare(gotPass[:], wantPass[:])
+		return userOk&passOk == 1
+	}
+}
+
+func basicAuthorized(req *http.Request, check func(string, string) bool) bool {
+	user, pass, ok := req.BasicAuth()
+	return ok && check(user, pass)
+}
+
+func unauthorized(w http.ResponseWriter, realm string) {
+	w.Header().Set("WWW-Authenticate", "Basic realm="+strconv.Quote(realm)+", charset=\"UTF-8\"")
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
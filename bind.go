@@ -0,0 +1,367 @@
+package goodie
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are tried in order when a time field does not have a layout tag
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04",
+}
+
+// LoadFromQuery will fill in the fields of the struct pointed to by iface from the query string
+func (odie *Odie) LoadFromQuery(iface interface{}) error {
+	odie.log().Debugf("LoadFromQuery %+v", iface)
+	return loadValues(iface, odie.Url.GetQuery, odie.log())
+}
+
+// LoadFromForm will fill in the fields of the struct pointed to by iface from the posted form, falling back to the query string
+func (odie *Odie) LoadFromForm(iface interface{}) error {
+	odie.log().Debugf("LoadFromForm %+v", iface)
+	if odie.Request.PostForm == nil {
+		if err := odie.ParseForm(); err != nil {
+			return err
+		}
+	}
+
+	return loadValues(iface, func(key string) string {
+		if vs := odie.Request.PostForm[key]; len(vs) > 0 {
+			return vs[0]
+		}
+		return odie.Url.GetQuery(key)
+	}, odie.log())
+}
+
+// QueryString returns the query value for key, or def if it is missing or empty
+func (odie *Odie) QueryString(key string, def string) string {
+	if q := odie.Url.GetQuery(key); len(q) > 0 {
+		return q
+	}
+	return def
+}
+
+// QueryInt returns the query value for key as an int, or def if it is missing or not an int
+func (odie *Odie) QueryInt(key string, def int64) int64 {
+	i, err := strconv.ParseInt(odie.Url.GetQuery(key), 10, 64)
+	if err != nil {
+		return def
+	}
+	return i
+}
+
+// QueryBool returns the query value for key as a bool, using the same values as the binder, or def if it is missing or not a bool
+func (odie *Odie) QueryBool(key string, def bool) bool {
+	q := odie.Url.GetQuery(key)
+	if len(q) == 0 {
+		return def
+	}
+	b, err := parseBool(q)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// loadValues will fill in the struct fields of iface, using get to look up the value for each key
+func loadValues(iface interface{}, get func(key string) string, log Logger) error {
+	rValue := reflect.ValueOf(iface)
+
+	switch rValue.Kind() {
+	case reflect.Ptr:
+		if rValue.IsNil() {
+			return fmt.Errorf("FromUrl: ptr is nil")
+		}
+		rValue = rValue.Elem()
+	default:
+		return fmt.Errorf("FromUrl: %T is not ptr", iface)
+	}
+
+	switch rValue.Kind() {
+	case reflect.Struct:
+		return loadStruct(rValue, get, log)
+	case reflect.Slice, reflect.Array:
+		return fmt.Errorf("FromUrl: Can not decode %T", iface)
+	case reflect.Map:
+		return fmt.Errorf("FromUrl: Can not decode %T", iface)
+	default:
+		return fmt.Errorf("FromUrl: Can not decode %T", iface)
+	}
+}
+
+// loadStruct will fill in the fields of the struct rValue.  Embedded structs share the keys of the parent,
+// named struct fields use keys prefixed with the field's key, such as address_city
+func loadStruct(rValue reflect.Value, get func(key string) string, log Logger) error {
+	for i := 0; i != rValue.NumField(); i++ {
+		fieldValue := rValue.Field(i)
+		field := rValue.Type().Field(i)
+
+		// the exported fields of an unexported embedded struct are promoted, so they are bound too
+		if !fieldValue.CanInterface() && !(field.Anonymous && isNestedStruct(field.Type)) {
+			continue
+		}
+
+		tag := fieldTag(field)
+		if _, skip := tag["-"]; skip {
+			continue
+		}
+		keys := fieldKeys(field, tag)
+		key := keys[0]
+
+		if isNestedStruct(field.Type) {
+			nestedGet := get
+			if !field.Anonymous {
+				prefix := underscoreKey(field.Name)
+				if k := tag["key"]; len(k) > 0 {
+					prefix = k
+				}
+				nestedGet = func(key string) string {
+					return get(prefix + "_" + key)
+				}
+			}
+			if err := loadStruct(fieldValue, nestedGet, log); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var q string
+		for _, key := range keys {
+			q = get(key)
+			log.Debugf("%s = '%s'", key, q)
+			if len(q) > 0 {
+				break
+			}
+		}
+		if len(q) == 0 {
+			continue
+		}
+
+		if err := setField(fieldValue, field, tag, key, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// isNestedStruct is true for a struct whose fields should be bound, rather than a value such as time.Time or sql.NullString
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	return !reflect.PtrTo(t).Implements(scannerType)
+}
+
+// setField will convert q to the field's type and set it
+func setField(fieldValue reflect.Value, field reflect.StructField, tag map[string]string, key string, q string) error {
+	t := field.Type.Kind()
+	switch t {
+	case reflect.String:
+		fieldValue.SetString(q)
+	case reflect.Int64, reflect.Int:
+		n, err := parseInt(q, tag)
+		if err != nil {
+			return fmt.Errorf("Not an int: %s = %s (%s)", key, q, err.Error())
+		}
+		fieldValue.SetInt(n)
+	case reflect.Bool:
+		b, err := parseBool(q)
+		if err != nil {
+			return fmt.Errorf("Not a bool: %s = %s (%s)", key, q, err.Error())
+		}
+		fieldValue.SetBool(b)
+	case reflect.Float64, reflect.Float32:
+		f, err := strconv.ParseFloat(q, field.Type.Bits())
+		if err != nil {
+			return fmt.Errorf("Not a float: %s = %s (%s)", key, q, err.Error())
+		}
+		fieldValue.SetFloat(f)
+	case reflect.Struct:
+		iface := fieldValue.Interface()
+		switch iface.(type) {
+		case sql.NullInt64:
+			n, err := parseInt(q, tag)
+			if err != nil {
+				return fmt.Errorf("Not an int: %s = %s (%s)", key, q, err.Error())
+			}
+			si64 := sql.NullInt64{
+				Valid: true,
+				Int64: n,
+			}
+			v := reflect.ValueOf(si64)
+			fieldValue.Set(v)
+		case sql.NullFloat64:
+			f, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				return fmt.Errorf("Not a float: %s = %s (%s)", key, q, err.Error())
+			}
+			fieldValue.Set(reflect.ValueOf(sql.NullFloat64{
+				Valid:   true,
+				Float64: f,
+			}))
+		case sql.NullString:
+			fieldValue.Set(reflect.ValueOf(sql.NullString{
+				Valid:  true,
+				String: q,
+			}))
+		case sql.NullBool:
+			b, err := parseBool(q)
+			if err != nil {
+				return fmt.Errorf("Not a bool: %s = %s (%s)", key, q, err.Error())
+			}
+			fieldValue.Set(reflect.ValueOf(sql.NullBool{
+				Valid: true,
+				Bool:  b,
+			}))
+		case time.Time:
+			t, err := parseTime(q, tag["layout"])
+			if err != nil {
+				return fmt.Errorf("Not a time: %s = %s (%s)", key, q, err.Error())
+			}
+			fieldValue.Set(reflect.ValueOf(t))
+		case sql.NullTime:
+			t, err := parseTime(q, tag["layout"])
+			if err != nil {
+				return fmt.Errorf("Not a time: %s = %s (%s)", key, q, err.Error())
+			}
+			fieldValue.Set(reflect.ValueOf(sql.NullTime{
+				Valid: true,
+				Time:  t,
+			}))
+		default:
+			return fmt.Errorf("Unsuported type %T %T for key: %s", field, iface, key)
+		}
+	default:
+		return fmt.Errorf("Unsuported type %T for key: %s", field, key)
+	}
+	return nil
+}
+
+// tagOptions are the names of the goodie tag options.  Any other text after a comma is part of the option before it
+var tagOptions = map[string]bool{
+	"-":        true,
+	"key":      true,
+	"layout":   true,
+	"enum":     true,
+	"required": true,
+	"email":    true,
+	"min":      true,
+	"max":      true,
+}
+
+// fieldTag will parse the goodie struct tag into its options, such as `goodie:"key=user_id,required,min=1"`.
+// Options are separated by , or ;.  A comma not followed by an option name is kept in the value, so a layout or
+// enum may have commas, e.g. `goodie:"layout=Jan 2, 2006;required"` or `goodie:"enum=active:1,inactive:0"`
+func fieldTag(field reflect.StructField) map[string]string {
+	tag := make(map[string]string)
+	last := ""
+	for _, part := range strings.Split(field.Tag.Get("goodie"), ";") {
+		last = ""
+		for _, opt := range strings.Split(part, ",") {
+			kv := strings.SplitN(strings.TrimSpace(opt), "=", 2)
+			if len(last) > 0 && !tagOptions[kv[0]] {
+				tag[last] += "," + opt
+				continue
+			}
+			if len(kv[0]) == 0 {
+				continue
+			}
+			last = kv[0]
+			if len(kv) == 1 {
+				tag[kv[0]] = ""
+			} else {
+				tag[kv[0]] = kv[1]
+			}
+		}
+	}
+	return tag
+}
+
+// fieldKeys returns the keys to look up for a field, in order.
+// An explicit `goodie:"key=name"` tag is the only key used, otherwise a quoted xorm column name is tried before the lower case and underscore forms of the field name
+func fieldKeys(field reflect.StructField, tag map[string]string) []string {
+	if key := tag["key"]; len(key) > 0 {
+		return []string{key}
+	}
+
+	keys := make([]string, 0, 3)
+	for _, opt := range strings.Fields(field.Tag.Get("xorm")) {
+		if len(opt) > 2 && strings.HasPrefix(opt, "'") && strings.HasSuffix(opt, "'") {
+			keys = append(keys, opt[1:len(opt)-1])
+			break
+		}
+	}
+	return append(keys, strings.ToLower(field.Name), underscoreKey(field.Name))
+}
+
+// parseInt will parse q as an int, or if the field has an enum tag such as `goodie:"enum=active:1,inactive:0"`, look up q by name
+func parseInt(q string, tag map[string]string) (int64, error) {
+	enum, ok := tag["enum"]
+	if !ok {
+		return strconv.ParseInt(q, 10, 64)
+	}
+
+	for _, nv := range strings.Split(enum, ",") {
+		kv := strings.SplitN(nv, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) != q {
+			continue
+		}
+		return strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+	}
+	return 0, fmt.Errorf("unknown enum value")
+}
+
+// parseTime will parse q using layout, or if layout is empty, each of the timeLayouts in order
+func parseTime(q string, layout string) (time.Time, error) {
+	if len(layout) > 0 {
+		return time.Parse(layout, q)
+	}
+
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		t, err = time.Parse(layout, q)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// parseBool accepts the values an HTML checkbox or select may send
+func parseBool(q string) (bool, error) {
+	switch strings.ToLower(q) {
+	case "1", "true", "on", "yes":
+		return true, nil
+	case "", "0", "false", "off", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid bool value")
+}
+
+func underscoreKey(key string) string {
+	runes := make([]rune, 0, len(key)*2)
+	for i, r := range key {
+		isUpper := 'A' <= r && r <= 'Z'
+		if isUpper {
+			if i > 0 {
+				runes = append(runes, '_')
+			}
+			r -= ('A' - 'a')
+		}
+		runes = append(runes, r)
+	}
+
+	return string(runes)
+}
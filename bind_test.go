@@ -0,0 +1,239 @@
+package goodie
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/debspencer/html"
+)
+
+// getValues returns a get func for loadValues looking up values
+func getValues(values map[string]string) func(key string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+type bindAddress struct {
+	City string
+	Zip  string
+}
+
+type bindAudit struct {
+	CreatedBy string
+}
+
+type bindPerson struct {
+	bindAudit
+	Name     string
+	Home     bindAddress
+	Work     bindAddress   `goodie:"key=office"`
+	Status   int           `goodie:"enum=active:1, inactive:0"`
+	Level    sql.NullInt64 `goodie:"enum=low:1,high:2"`
+	Born     time.Time     `goodie:"layout=Jan 2, 2006"`
+	Seen     time.Time
+	Until    sql.NullTime `goodie:"layout=2006-01-02"`
+	Admin    bool
+	Verified sql.NullBool
+}
+
+func TestLoadValues(t *testing.T) {
+	var p bindPerson
+	err := loadValues(&p, getValues(map[string]string{
+		"created_by":  "root",
+		"name":        "ann",
+		"home_city":   "Oslo",
+		"home_zip":    "0150",
+		"office_city": "Bergen",
+		"work_city":   "ignored, the key tag is the prefix",
+		"status":      "inactive",
+		"level":       "high",
+		"born":        "Mar 4, 1990",
+		"seen":        "2020-05-06 07:08",
+		"until":       "2021-01-02",
+		"admin":       "on",
+		"verified":    "no",
+	}), NopLogger)
+	if err != nil {
+		t.Fatalf("loadValues: %s", err)
+	}
+
+	want := bindPerson{
+		bindAudit: bindAudit{CreatedBy: "root"},
+		Name:      "ann",
+		Home:      bindAddress{City: "Oslo", Zip: "0150"},
+		Work:      bindAddress{City: "Bergen"},
+		Status:    0,
+		Level:     sql.NullInt64{Int64: 2, Valid: true},
+		Born:      time.Date(1990, 3, 4, 0, 0, 0, 0, time.UTC),
+		Seen:      time.Date(2020, 5, 6, 7, 8, 0, 0, time.UTC),
+		Until:     sql.NullTime{Time: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Valid: true},
+		Admin:     true,
+		Verified:  sql.NullBool{Bool: false, Valid: true},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("loadValues =\n%+v\nwant\n%+v", p, want)
+	}
+}
+
+func TestLoadValuesErrors(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{"status", "retired"},
+		{"level", "3"},
+		{"born", "1990-03-04"},
+		{"seen", "yesterday"},
+		{"admin", "maybe"},
+		{"verified", "2"},
+	}
+	for _, tt := range tests {
+		var p bindPerson
+		if err := loadValues(&p, getValues(map[string]string{tt.key: tt.value}), NopLogger); err == nil {
+			t.Errorf("loadValues %s = %q did not fail", tt.key, tt.value)
+		}
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	tests := []struct {
+		q       string
+		want    bool
+		wantErr bool
+	}{
+		{"1", true, false},
+		{"true", true, false},
+		{"ON", true, false},
+		{"yes", true, false},
+		{"", false, false},
+		{"0", false, false},
+		{"False", false, false},
+		{"off", false, false},
+		{"no", false, false},
+		{"y", false, true},
+	}
+	for _, tt := range tests {
+		got, err := parseBool(tt.q)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseBool(%q) = %t, %v", tt.q, got, err)
+		}
+	}
+}
+
+type bindSignup struct {
+	FirstName string
+	LastName  string
+	Age       int
+	Agree     bool
+	Source    string
+}
+
+func TestLoadFromForm(t *testing.T) {
+	tests := []struct {
+		name      string
+		autoParse bool
+		target    string
+		body      string
+		want      bindSignup
+	}{
+		{"underscore keys", true, "/app/signup", "first_name=Ann&last_name=Lee&age=30&agree=on",
+			bindSignup{FirstName: "Ann", LastName: "Lee", Age: 30, Agree: true}},
+		{"lower case keys", true, "/app/signup", "firstname=Ann&lastname=Lee",
+			bindSignup{FirstName: "Ann", LastName: "Lee"}},
+		{"query fallback", true, "/app/signup?source=ad&age=1", "first_name=Ann&age=30",
+			bindSignup{FirstName: "Ann", Age: 30, Source: "ad"}},
+		{"without AutoParseForm", false, "/app/signup?source=ad", "first_name=Ann&age=30",
+			bindSignup{FirstName: "Ann", Age: 30, Source: "ad"}},
+	}
+	for _, tt := range tests {
+		s := newTestServer()
+		app := s.NewApp("app")
+		app.AutoParseForm = tt.autoParse
+		var got bindSignup
+		var err error
+		app.RegisterPost("signup", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+			err = p.LoadFromForm(&got)
+			return nil, nil, nil
+		}))
+
+		serveTest(s, "POST", tt.target, tt.body)
+		if err != nil {
+			t.Errorf("%s: LoadFromForm: %s", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: LoadFromForm = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+type bindKeys struct {
+	UserID   int64  `goodie:"key=user_id"`
+	TeamID   int64  `xorm:"'team_id' index"`
+	OrgID    int64  `xorm:"'org' index" goodie:"key=organization"`
+	Password string `goodie:"-"`
+	NickName string
+}
+
+func TestFieldKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   bindKeys
+	}{
+		{"tags", map[string]string{"user_id": "1", "team_id": "2", "organization": "3", "password": "x", "nick_name": "ann"},
+			bindKeys{UserID: 1, TeamID: 2, OrgID: 3, NickName: "ann"}},
+		// a key tag is the only key, the derived keys are not tried
+		{"key tag only", map[string]string{"userid": "1", "user_i_d": "1", "org": "3", "orgid": "3"},
+			bindKeys{}},
+		// the xorm column is tried before the derived keys, which are the fallback
+		{"xorm column first", map[string]string{"team_id": "2", "teamid": "5"}, bindKeys{TeamID: 2}},
+		{"derived keys", map[string]string{"teamid": "5", "nickname": "ann"}, bindKeys{TeamID: 5, NickName: "ann"}},
+		{"skipped", map[string]string{"password": "x", "Password": "x"}, bindKeys{}},
+	}
+	for _, tt := range tests {
+		var got bindKeys
+		if err := loadValues(&got, getValues(tt.values), NopLogger); err != nil {
+			t.Errorf("%s: loadValues: %s", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: loadValues = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFieldTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want map[string]string
+	}{
+		{`goodie:"key=user_id,required"`, map[string]string{"key": "user_id", "required": ""}},
+		{`goodie:"key=user_id;required;min=2"`, map[string]string{"key": "user_id", "required": "", "min": "2"}},
+		{`goodie:"required, min=1, max=100"`, map[string]string{"required": "", "min": "1", "max": "100"}},
+		{`goodie:"layout=Jan 2, 2006"`, map[string]string{"layout": "Jan 2, 2006"}},
+		{`goodie:"layout=Jan 2, 2006;required"`, map[string]string{"layout": "Jan 2, 2006", "required": ""}},
+		{`goodie:"enum=active:1, inactive:0,required"`, map[string]string{"enum": "active:1, inactive:0", "required": ""}},
+		{`goodie:"-"`, map[string]string{"-": ""}},
+		{``, map[string]string{}},
+	}
+	for _, tt := range tests {
+		field := reflect.StructField{Name: "F", Tag: reflect.StructTag(tt.tag)}
+		if got := fieldTag(field); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("fieldTag(%s) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestLoadKeyTagWithRules(t *testing.T) {
+	var v struct {
+		UserID string `goodie:"key=user_id,required,min=2"`
+	}
+	if err := loadValues(&v, getValues(map[string]string{"user_id": "42"}), NopLogger); err != nil {
+		t.Fatalf("loadValues: %s", err)
+	}
+	if v.UserID != "42" {
+		t.Errorf("UserID = %q, want 42", v.UserID)
+	}
+}
@@ -0,0 +1,43 @@
+package goodie
+
+import (
+	"strings"
+
+	"github.com/debspencer/html"
+)
+
+// Breadcrumbs builds the stack of urls shown in the header, such as index -> page1 -> page2.
+// The last url is the current page, and is shown as text
+type Breadcrumbs struct {
+	odie *Odie
+	urls []*html.URL
+}
+
+// Breadcrumbs returns the breadcrumbs of the page.  If any are added in Init, they are used in place of the urls Init returns
+func (odie *Odie) Breadcrumbs() *Breadcrumbs {
+	if odie.breadcrumbs == nil {
+		odie.breadcrumbs = &Breadcrumbs{odie: odie}
+	}
+	return odie.breadcrumbs
+}
+
+// Add will add a link to path named name.  A path without a leading / is relative to the App, such as list/items
+func (b *Breadcrumbs) Add(name string, path string) *Breadcrumbs {
+	if !strings.HasPrefix(path, "/") && b.odie.app != nil {
+		path = b.odie.app.mount + "/" + path
+	}
+	u := html.NewLink(path)
+	u.Name = name
+	return b.AddURL(u)
+}
+
+// AddURL will add u, its Name is shown
+func (b *Breadcrumbs) AddURL(u *html.URL) *Breadcrumbs {
+	b.urls = append(b.urls, u)
+	return b
+}
+
+// URLs returns the urls added so far
+func (b *Breadcrumbs) URLs() []*html.URL {
+	return b.urls
+}
@@ -0,0 +1,57 @@
+package goodie
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	defaultExpires      = "Sat, Jan 1 2000 00:00:00 GMT"
+	defaultCacheControl = "no-cache, no-store, must-revalidate"
+)
+
+// SetCacheControl sets the Cache-Control of every page, overriding the default of no-cache, no-store, must-revalidate.
+// directive is such as public or private, a maxAge > 0 adds max-age
+func (s *Server) SetCacheControl(directive string, maxAge time.Duration) {
+	s.cacheControl = cacheControl(directive, maxAge)
+}
+
+// SetCacheControl sets the Cache-Control of this page, it must be called before the response is written.
+// directive is such as public or private, a maxAge > 0 adds max-age
+func (odie *Odie) SetCacheControl(directive string, maxAge time.Duration) {
+	h := odie.Response.Header()
+	h.Del("Expires")
+	h.Set("Cache-Control", cacheControl(directive, maxAge))
+	odie.cacheSet = true
+}
+
+// setCacheHeaders will set the server's cache control, or the no-cache default
+func (odie *Odie) setCacheHeaders() {
+	h := odie.Response.Header()
+	if cc := odie.server().cacheControl; len(cc) > 0 {
+		h.Set("Cache-Control", cc)
+		odie.cacheSet = true
+		return
+	}
+	h.Set("Expires", defaultExpires)
+	h.Set("Cache-Control", defaultCacheControl)
+}
+
+// noStoreHeaders will keep an error response out of caches, whatever the page set before it failed
+func noStoreHeaders(h http.Header) {
+	h.Del("ETag")
+	h.Set("Expires", defaultExpires)
+	h.Set("Cache-Control", defaultCacheControl)
+}
+
+func cacheControl(directive string, maxAge time.Duration) string {
+	if maxAge <= 0 {
+		return directive
+	}
+	age := "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+	if len(directive) == 0 {
+		return age
+	}
+	return directive + ", " + age
+}
@@ -0,0 +1,173 @@
+package goodie
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// DefaultCompressMinSize is the smallest response Compress will gzip
+var DefaultCompressMinSize = 1024
+
+// compressedTypes are content types which are already compressed
+var compressedTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/pdf",
+}
+
+// Compress is middleware that gzips responses of at least DefaultCompressMinSize bytes for clients which accept gzip
+func Compress() Middleware {
+	return CompressMin(DefaultCompressMinSize)
+}
+
+// CompressMin is Compress with a minimum response size.  Content which is already compressed, such as images,
+// and range requests for static files are not compressed
+func CompressMin(minSize int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(req) || len(req.Header.Get("Range")) > 0 {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			gw := &gzipWriter{ResponseWriter: w, minSize: minSize}
+			defer gw.close()
+			next.ServeHTTP(gw, req)
+		})
+	}
+}
+
+// acceptsGzip checks the Accept-Encoding header has gzip, without a q of 0
+func acceptsGzip(req *http.Request) bool {
+	for _, enc := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(enc, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if q := strings.TrimSpace(p); q == "q=0" || q == "q=0.0" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter buffers the start of the response, until it knows if the response is worth compressing
+type gzipWriter struct {
+	http.ResponseWriter
+	minSize int
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer // set if compressing
+}
+
+func (g *gzipWriter) WriteHeader(status int) {
+	if g.status == 0 {
+		g.status = status
+	}
+}
+
+func (g *gzipWriter) Write(p []byte) (int, error) {
+	if g.decided {
+		return g.write(p)
+	}
+	g.buf = append(g.buf, p...)
+	if len(g.buf) >= g.minSize {
+		if err := g.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (g *gzipWriter) write(p []byte) (int, error) {
+	if g.gz != nil {
+		return g.gz.Write(p)
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+// decide will write the header, compressing if big enough and the content is not already compressed,
+// then write the buffered data
+func (g *gzipWriter) decide(big bool) error {
+	g.decided = true
+	h := g.Header()
+	if len(h.Get("Content-Type")) == 0 && len(g.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(g.buf))
+	}
+
+	if big && g.compressible() {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+
+	if g.status != 0 {
+		g.ResponseWriter.WriteHeader(g.status)
+	}
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := g.write(buf)
+	return err
+}
+
+func (g *gzipWriter) compressible() bool {
+	h := g.Header()
+	if len(h.Get("Content-Encoding")) > 0 || len(h.Get("Content-Range")) > 0 {
+		return false
+	}
+	switch g.status {
+	case 0, http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNotFound, http.StatusInternalServerError:
+	default:
+		return false
+	}
+
+	ct := strings.ToLower(h.Get("Content-Type"))
+	for _, t := range compressedTypes {
+		if strings.HasPrefix(ct, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Flush keeps streaming responses such as TailFile working, a streamed response is compressed
+func (g *gzipWriter) Flush() {
+	if !g.decided {
+		g.decide(true)
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying ResponseWriter
+func (g *gzipWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// close will write a small response uncompressed, or finish the gzip stream
+func (g *gzipWriter) close() {
+	if !g.decided {
+		g.decide(false)
+	}
+	if g.gz != nil {
+		g.gz.Close()
+	}
+}
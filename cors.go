@@ -0,0 +1,87 @@
+package goodie
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures cross origin requests, see Server.SetCORS
+type CORSOptions struct {
+	AllowedOrigins   []string      // origins such as https://admin.example.com, * allows any origin
+	AllowedMethods   []string      // defaults to GET, HEAD and POST
+	AllowedHeaders   []string      // request headers allowed in addition to the simple headers
+	AllowCredentials bool          // allow cookies and authorization, an allowed origin is echoed rather than *
+	MaxAge           time.Duration // how long a preflight may be cached, 0 leaves it to the browser
+}
+
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// SetCORS will answer OPTIONS preflight requests from the allowed origins, and add the Access-Control headers to
+// their responses.  Requests from other origins do not get the headers, so the browser blocks them
+func (s *Server) SetCORS(opts CORSOptions) {
+	if len(opts.AllowedMethods) == 0 {
+		opts.AllowedMethods = defaultCORSMethods
+	}
+	s.cors = &opts
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or an empty string if it is not allowed
+func (c *CORSOptions) allowOrigin(origin string) string {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			if c.AllowCredentials {
+				return origin
+			}
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// handleCORS will add the CORS headers for the request, returning true if it was a preflight which has been answered
+func (s *Server) handleCORS(w http.ResponseWriter, req *http.Request) bool {
+	c := s.cors
+	origin := req.Header.Get("Origin")
+	if c == nil || len(origin) == 0 {
+		return false
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	preflight := req.Method == http.MethodOptions && len(req.Header.Get("Access-Control-Request-Method")) > 0
+
+	allow := c.allowOrigin(origin)
+	if len(allow) == 0 {
+		if preflight {
+			s.log().Infof("CORS origin %s not allowed for '%s'", origin, req.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Origin", allow)
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		return false
+	}
+
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	h.Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+	if len(c.AllowedHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+	}
+	if c.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
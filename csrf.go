@@ -0,0 +1,84 @@
+package goodie
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+var csrfField = "_csrf"
+
+// PublicAction will exclude actions from the CheckCSRF verification
+func (a *App) PublicAction(actions ...string) {
+	if a.publicActions == nil {
+		a.publicActions = make(map[string]bool)
+	}
+	for _, action := range actions {
+		a.publicActions[action] = true
+	}
+}
+
+// CSRFToken will return the CSRF token of the session, creating and saving it if needed.
+// Returns an empty string if the Server secret is not set
+func (odie *Odie) CSRFToken() string {
+	server := odie.server()
+	if server == nil || len(server.secret) == 0 {
+		return ""
+	}
+
+	session := odie.Session()
+	token := session.Get(csrfField)
+	if len(token) > 0 {
+		return token
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	session.Set(csrfField, token)
+	if err := session.Save(); err != nil {
+		return ""
+	}
+	return token
+}
+
+// CheckCSRF will return InvalidCSRF unless the request has the CSRF token of the session.
+// The token is read from a url encoded body or the query string, a multipart body is not read
+// so StreamUploads can still stream it.  A multipart form should have the token in its action url,
+// or call CheckCSRF after StreamUploads has added the form values
+func (odie *Odie) CheckCSRF() error {
+	token := odie.Session().Get(csrfField)
+	if len(token) == 0 {
+		return InvalidCSRF
+	}
+	// ParseForm only reads a url encoded body, FormValue would buffer a multipart one
+	odie.Request.ParseForm()
+	got := odie.Request.Form.Get(csrfField)
+	if len(got) == 0 {
+		got = odie.Request.PostForm.Get(csrfField)
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		return InvalidCSRF
+	}
+	return nil
+}
+
+// checkOverrideCSRF will check the CSRF token of a request with a _method override, if the App has CheckCSRF
+func (odie *Odie) checkOverrideCSRF() error {
+	if odie.app == nil || !odie.app.CheckCSRF {
+		return nil
+	}
+	if _, ok := odie.Request.Context().Value(overrideKey{}).(string); !ok {
+		return nil
+	}
+	return odie.CheckCSRF()
+}
+
+func (odie *Odie) checkActionCSRF(action string) error {
+	if odie.app == nil || !odie.app.CheckCSRF || odie.app.publicActions[action] {
+		return nil
+	}
+	return odie.CheckCSRF()
+}
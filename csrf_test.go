@@ -0,0 +1,174 @@
+package goodie
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+var csrfInput = regexp.MustCompile(`name="_csrf"[^>]* value="([^"]*)"`)
+
+// csrfServer returns a server with a form page rendering NewForm, or NewPublicForm if public
+func csrfServer(secret bool, public bool) *Server {
+	s := newTestServer()
+	if secret {
+		s.SetSecret([]byte("secret"))
+	}
+	app := s.NewApp("app")
+	app.CheckCSRF = true
+	app.PublicAction("search")
+	app.Register("form", func() Handler {
+		return &testPage{display: func(p *testPage) {
+			if public {
+				p.Body.Add(p.NewPublicForm("save"))
+			} else {
+				p.Body.Add(p.NewForm("save"))
+			}
+		}}
+	})
+	return s
+}
+
+// csrfForm will render the form page, returning its session cookie and CSRF token
+func csrfForm(t *testing.T, s *Server) (*http.Cookie, string) {
+	t.Helper()
+	rec := serveTest(s, "GET", "/app/form", "")
+	if rec.Code != 200 {
+		t.Fatalf("GET /app/form = %d", rec.Code)
+	}
+	var token string
+	if m := csrfInput.FindStringSubmatch(rec.Body.String()); m != nil {
+		token = m[1]
+	}
+	return sessionCookie(t, rec, nil), token
+}
+
+func TestCSRFToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    bool
+		public    bool
+		wantToken bool
+	}{
+		{"NewForm", true, false, true},
+		{"NewPublicForm", true, true, false},
+		{"without a secret", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, token := csrfForm(t, csrfServer(tt.secret, tt.public))
+			if (len(token) > 0) != tt.wantToken {
+				t.Errorf("rendered token = %q, want a token %v", token, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestCheckCSRF(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret bool
+		action string
+		token  string // "session" sends the rendered token
+		want   int
+	}{
+		{"session token", true, "save", "session", 200},
+		{"no token", true, "save", "", 403},
+		{"wrong token", true, "save", "wrong", 403},
+		{"public action without a token", true, "search", "", 200},
+		{"public action with a wrong token", true, "search", "wrong", 200},
+		{"without a secret", false, "save", "", 403},
+		{"without a secret and a token", false, "save", "wrong", 403},
+		{"public action without a secret", false, "search", "", 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := csrfServer(tt.secret, false)
+			cookie, token := csrfForm(t, s)
+			if tt.token != "session" {
+				token = tt.token
+			}
+
+			form := url.Values{}
+			if len(token) > 0 {
+				form.Set(csrfField, token)
+			}
+			req := httptest.NewRequest("POST", "/app/form?action="+tt.action, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if cookie != nil {
+				req.AddCookie(cookie)
+			}
+			if rec := serveRequest(s, req); rec.Code != tt.want {
+				t.Errorf("POST action=%s with %q = %d, want %d", tt.action, tt.token, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCSRFMultipart(t *testing.T) {
+	tests := []struct {
+		name  string
+		check bool // call CheckCSRF in Init before StreamUploads
+		query bool
+		body  bool
+		want  int
+	}{
+		{"token in the action url", false, true, false, 200},
+		{"token in the body streamed in Init", false, false, true, 200},
+		{"no token", false, false, false, 403},
+		{"checked before streaming", true, true, false, 200},
+		{"checked before streaming without a token", true, false, false, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := csrfServer(true, false)
+			app := s.NewApp("upload")
+			app.CheckCSRF = true
+			app.AutoParseForm = false
+			var streamed string
+			app.Register("form", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+				if tt.check {
+					if err := p.CheckCSRF(); err != nil {
+						return nil, nil, err
+					}
+				}
+				if err := p.StreamUploads("", 1<<10, 1<<20, nil); err != nil {
+					return nil, nil, err
+				}
+				streamed = p.Request.PostForm.Get("name")
+				return nil, nil, nil
+			}))
+			cookie, token := csrfForm(t, s)
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			if tt.body {
+				mw.WriteField(csrfField, token)
+			}
+			mw.WriteField("name", "Ann")
+			mw.Close()
+			target := "/upload/form?action=save"
+			if tt.query {
+				target += "&" + csrfField + "=" + url.QueryEscape(token)
+			}
+			req := httptest.NewRequest("POST", target, &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			req.AddCookie(cookie)
+
+			rec := serveRequest(s, req)
+			if rec.Code != tt.want {
+				t.Errorf("multipart POST = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == 200 && streamed != "Ann" {
+				t.Errorf("streamed name = %q, want the body left for StreamUploads", streamed)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package goodie
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// csvField is a struct field exported as a csv column
+type csvField struct {
+	index  int
+	header string
+}
+
+// RenderCSV will write the slice of structs v as a csv attachment, no document will be rendered.
+// columns are field or xorm column names, when empty every exported field is written in declaration order.
+// Headers are the xorm column name, or the field name.  Null values are written as empty
+func (odie *Odie) RenderCSV(v interface{}, columns ...string) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("RenderCSV: %T is not a slice", v)
+	}
+	t := rv.Type().Elem()
+	ptr := t.Kind() == reflect.Ptr
+	if ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("RenderCSV: %T is not a slice of structs", v)
+	}
+
+	fields, err := csvFields(t, columns)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	record := make([]string, len(fields))
+	for i, f := range fields {
+		record[i] = f.header
+	}
+	w.Write(record)
+
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		if ptr {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		for j, f := range fields {
+			record[j] = formatValue(elem.Field(f.index).Interface(), "")
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	h := odie.Response.Header()
+	h.Set("Content-type", "text/csv; charset=utf-8")
+	h.Set("Content-Disposition", `attachment; filename="`+underscoreKey(t.Name())+`.csv"`)
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	odie.Response.WriteHeader(http.StatusOK)
+	odie.Response.Write(buf.Bytes())
+	odie.committed = true
+	return nil
+}
+
+// csvFields returns the fields of t for columns, or every exported field
+func csvFields(t reflect.Type, columns []string) ([]csvField, error) {
+	var all []csvField
+	byName := make(map[string]csvField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		tag := fieldTag(field)
+		if _, skip := tag["-"]; skip {
+			continue
+		}
+
+		f := csvField{index: i, header: csvHeader(field)}
+		all = append(all, f)
+		byName[field.Name] = f
+		for _, key := range fieldKeys(field, tag) {
+			if _, ok := byName[key]; !ok {
+				byName[key] = f
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		return all, nil
+	}
+	fields := make([]csvField, 0, len(columns))
+	for _, col := range columns {
+		f, ok := byName[col]
+		if !ok {
+			return nil, fmt.Errorf("RenderCSV: unknown column %s for %s", col, t.Name())
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// csvHeader is the xorm column name of the field, or the field name
+func csvHeader(field reflect.StructField) string {
+	for _, opt := range strings.Fields(field.Tag.Get("xorm")) {
+		if len(opt) > 2 && strings.HasPrefix(opt, "'") && strings.HasSuffix(opt, "'") {
+			return opt[1 : len(opt)-1]
+		}
+	}
+	return field.Name
+}
@@ -0,0 +1,78 @@
+package goodie
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+)
+
+type csvOrder struct {
+	Id       int64
+	Customer string `xorm:"'customer_name'"`
+	Note     sql.NullString
+	Total    float64
+	Secret   string `goodie:"-"`
+	internal string
+}
+
+func TestRenderCSV(t *testing.T) {
+	orders := []*csvOrder{
+		{Id: 1, Customer: "Ann, Inc", Note: sql.NullString{String: `says "hi"`, Valid: true}, Total: 9.5, Secret: "x"},
+		nil,
+		{Id: 2, Customer: "Bob", Total: 0},
+	}
+
+	tests := []struct {
+		name    string
+		columns []string
+		want    string
+	}{
+		{"all", nil, "Id,customer_name,Note,Total\n1,\"Ann, Inc\",\"says \"\"hi\"\"\",9.5\n2,Bob,,0\n"},
+		{"columns", []string{"Total", "customer_name", "id"}, "Total,customer_name,Id\n9.5,\"Ann, Inc\",1\n0,Bob,2\n"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		odie := &Odie{Response: rec}
+		if err := odie.RenderCSV(orders, tt.columns...); err != nil {
+			t.Errorf("%s: RenderCSV: %s", tt.name, err)
+			continue
+		}
+		if !odie.committed {
+			t.Errorf("%s: RenderCSV did not commit the response", tt.name)
+		}
+		if got := rec.Body.String(); got != tt.want {
+			t.Errorf("%s: RenderCSV =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+		h := rec.Result().Header
+		if ct := h.Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+			t.Errorf("%s: Content-Type = %q", tt.name, ct)
+		}
+		if cd := h.Get("Content-Disposition"); cd != `attachment; filename="csv_order.csv"` {
+			t.Errorf("%s: Content-Disposition = %q", tt.name, cd)
+		}
+	}
+}
+
+func TestRenderCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		columns []string
+	}{
+		{"not a slice", csvOrder{}, nil},
+		{"not structs", []string{"a"}, nil},
+		{"unknown column", []csvOrder{}, []string{"nope"}},
+		{"skipped column", []csvOrder{}, []string{"Secret"}},
+		{"unexported column", []csvOrder{}, []string{"internal"}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		odie := &Odie{Response: rec}
+		if err := odie.RenderCSV(tt.v, tt.columns...); err == nil {
+			t.Errorf("%s: RenderCSV did not fail", tt.name)
+		}
+		if odie.committed || rec.Body.Len() > 0 {
+			t.Errorf("%s: RenderCSV wrote a response after failing", tt.name)
+		}
+	}
+}
@@ -0,0 +1,650 @@
+package goodie
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-xorm/xorm"
+	"xorm.io/builder"
+	"xorm.io/core"
+)
+
+var defaultPerPage = 25
+
+// Sort is one column of the order for GetSorted
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// DbOptions configures the database connection pool and logging for an App
+type DbOptions struct {
+	MaxOpenConns    int           // 0 defaults to 5
+	MaxIdleConns    int           // 0 uses the database/sql default
+	ConnMaxLifetime time.Duration // 0 reuses connections forever
+	ShowSQL         bool          // log each SQL statement, defaults to true
+
+	// AcquireTimeout, if set, fails a Db helper with DbBusy when no connection is free within the timeout,
+	// rather than waiting for one.  It is a best effort probe, the query itself may still wait for a connection
+	AcquireTimeout time.Duration
+}
+
+// SetDbOptions will set the database options.  If called after SetDb, they are applied to the open database
+func (a *App) SetDbOptions(opts DbOptions) {
+	a.dbOptions = opts
+	if a.orm != nil {
+		a.applyDbOptions()
+	}
+}
+
+func (a *App) applyDbOptions() {
+	opts := a.dbOptions
+	if opts.MaxOpenConns <= 0 {
+		// 0 would be unlimited in database/sql
+		opts.MaxOpenConns = defaultMaxOpenConns
+	}
+	a.orm.SetMaxOpenConns(opts.MaxOpenConns)
+	if opts.MaxIdleConns > 0 {
+		a.orm.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	a.orm.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	a.orm.ShowSQL(opts.ShowSQL)
+}
+
+// DbStats returns the connection pool statistics of the database, for monitoring saturation
+func (a *App) DbStats() sql.DBStats {
+	if a.orm == nil {
+		return sql.DBStats{}
+	}
+	return a.orm.DB().Stats()
+}
+
+// RetryPolicy retries opening a database which is not up yet, such as at startup in a container
+type RetryPolicy struct {
+	Attempts int           // total attempts, 0 or 1 is a single attempt
+	Backoff  time.Duration // wait before the second attempt, doubled for each attempt after
+}
+
+// SetDb will open a sqlite3 database, db is relative to the server home
+func (a *App) SetDb(db string, retry ...RetryPolicy) error {
+	return a.SetDbDriver("sqlite3", a.odie.Path(db), retry...)
+}
+
+// SetDbDriver will open a database using any registered database/sql driver, the dsn is used as is.
+// The driver must be imported by the caller, e.g. _ "github.com/go-sql-driver/mysql".
+// With a retry policy the database is pinged, and opening is retried until the ping succeeds, returning the last error
+func (a *App) SetDbDriver(driver string, dsn string, retry ...RetryPolicy) error {
+	a.odie.log().Infof("SetDB %s %s", driver, dsn)
+	if len(retry) == 0 {
+		return a.openDb(driver, dsn, false)
+	}
+
+	policy := retry[0]
+	backoff := policy.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = a.openDb(driver, dsn, true)
+		if err == nil || attempt >= policy.Attempts {
+			return err
+		}
+		a.odie.log().Infof("SetDB %s attempt %d: %s", driver, attempt, err.Error())
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (a *App) openDb(driver string, dsn string, ping bool) error {
+	orm, err := xorm.NewEngine(driver, dsn)
+
+	if err != nil {
+		return err
+	}
+	if ping {
+		if err := orm.Ping(); err != nil {
+			orm.Close()
+			return err
+		}
+	}
+
+	orm.SetColumnMapper(core.SnakeMapper{})
+	//	orm.SetLogger(&logger{})
+	a.orm = orm
+	a.applyDbOptions()
+
+	return nil
+}
+
+// SyncModels will create or alter the tables of models to match their structs, using xorm's Sync2.
+// It must be called after SetDb, and should be called before Server.Run so the tables exist for the first request
+func (a *App) SyncModels(models ...interface{}) error {
+	if a.orm == nil {
+		return fmt.Errorf("DB not configured, call SetDb before SyncModels")
+	}
+	return a.orm.Sync2(models...)
+}
+
+// DropModels will drop the tables of models, such as for test teardown
+func (a *App) DropModels(models ...interface{}) error {
+	if a.orm == nil {
+		return fmt.Errorf("DB not configured, call SetDb before DropModels")
+	}
+	return a.orm.DropTables(models...)
+}
+
+// dbOps implements the Db helpers on either the engine, or the session of a transaction
+type dbOps struct {
+	db             xorm.Interface
+	engine         *xorm.Engine
+	ctx            context.Context // queries on the engine are cancelled with ctx
+	readOnly       bool
+	acquireTimeout time.Duration // 0 within a transaction, which already has its connection
+}
+
+// DbCtx has the Db helpers of Odie, with queries cancelled by its context
+type DbCtx struct {
+	dbOps
+}
+
+func (odie *Odie) ops() dbOps {
+	ops := dbOps{
+		db:     odie.Orm,
+		engine: odie.Orm,
+	}
+	if odie.Request != nil {
+		ops.ctx = odie.Request.Context()
+	}
+	if odie.app != nil {
+		ops.readOnly = odie.app.ReadOnly
+		ops.acquireTimeout = odie.app.dbOptions.AcquireTimeout
+	}
+	return ops
+}
+
+// DbContext returns the Db helpers with ctx in place of the request context, such as one with a timeout
+func (odie *Odie) DbContext(ctx context.Context) *DbCtx {
+	ops := odie.ops()
+	ops.ctx = ctx
+	return &DbCtx{dbOps: ops}
+}
+
+// q returns where to run a query, a new session with the context for the engine, or the transaction's session
+func (ops dbOps) q() xorm.Interface {
+	if ops.ctx != nil && ops.db == xorm.Interface(ops.engine) {
+		return ops.engine.Context(ops.ctx)
+	}
+	return ops.db
+}
+
+func (ops dbOps) configured() error {
+	if ops.engine == nil {
+		return fmt.Errorf("DB not configured")
+	}
+	return ops.probeConnection()
+}
+
+// probeConnection is a best effort check that a connection can be had within the acquire timeout.
+// The connection goes back to the pool before the query runs, which can still wait if another request takes it
+func (ops dbOps) probeConnection() error {
+	if ops.acquireTimeout <= 0 {
+		return nil
+	}
+
+	ctx := ops.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, ops.acquireTimeout)
+	defer cancel()
+
+	conn, err := ops.engine.DB().Conn(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return DbBusy
+	}
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// writable is configured, and also checks the App is not read only
+func (ops dbOps) writable() error {
+	if err := ops.configured(); err != nil {
+		return err
+	}
+	if ops.readOnly {
+		return ReadOnlyError
+	}
+	return nil
+}
+
+func (odie *Odie) DbInsert(v interface{}) error {
+	return odie.ops().DbInsert(v)
+}
+
+// DbInsertMany will insert a slice of records in one statement, returning the number inserted
+func (odie *Odie) DbInsertMany(v interface{}) (int64, error) {
+	return odie.ops().DbInsertMany(v)
+}
+func (odie *Odie) DbGet(id int64, v interface{}) error {
+	return odie.ops().DbGet(id, v)
+}
+
+// DbDelete will remove the record v, even if its model has a soft delete column
+func (odie *Odie) DbDelete(v interface{}) error {
+	return odie.ops().DbDelete(v)
+}
+
+// DbSoftDelete will set the deleted at column of the record id rather than removing it.
+// The column is the time field tagged `xorm:"deleted"`, soft deleted records are then excluded by the finders
+func (odie *Odie) DbSoftDelete(id int64, v interface{}) error {
+	return odie.ops().DbSoftDelete(id, v)
+}
+func (odie *Odie) DbUpdate(id int64, v interface{}) error {
+	return odie.ops().DbUpdate(id, v)
+}
+
+// DbUpsert will update the record id from v, or insert v with the id if there is no record, in one transaction
+func (odie *Odie) DbUpsert(id int64, v interface{}) error {
+	return odie.ops().DbUpsert(id, v)
+}
+
+// DbUpdateCols will update only cols of the record id from v, zero values such as 0, "" and false are written
+func (odie *Odie) DbUpdateCols(id int64, v interface{}, cols ...string) error {
+	return odie.ops().DbUpdateCols(id, v, cols...)
+}
+
+// DbPatch will update only the columns named in changes for the record id.
+// The column names are checked against the bean's table, bean is only used to find the table
+func (odie *Odie) DbPatch(id int64, bean interface{}, changes map[string]interface{}) error {
+	return odie.ops().DbPatch(id, bean, changes)
+}
+
+func (odie *Odie) GetAll(v interface{}) error {
+	return odie.ops().GetAll(v)
+}
+
+func (odie *Odie) GetOrder(v interface{}, order string) error {
+	return odie.ops().GetOrder(v, order)
+}
+
+// GetSorted will find all records into the slice pointed to by v, ordered by sorts.
+// The columns are checked against the table, so they are safe to take from user input
+func (odie *Odie) GetSorted(v interface{}, sorts ...Sort) error {
+	return odie.ops().GetSorted(v, sorts...)
+}
+
+// GetPage will find one page of records into the slice pointed to by v, and return the total number of records.
+// page is 1 based, perPage <= 0 uses a default of 25.  A page past the end returns no records
+func (odie *Odie) GetPage(v interface{}, page int, perPage int, order string) (int64, error) {
+	return odie.ops().GetPage(v, page, perPage, order)
+}
+
+// Find will find the records matching cond into the slice pointed to by v, e.g. builder.Eq{"name": name}
+func (odie *Odie) Find(v interface{}, cond builder.Cond) error {
+	return odie.ops().Find(v, cond)
+}
+
+// FindBy will find the records where column equals value into the slice pointed to by v
+func (odie *Odie) FindBy(v interface{}, column string, value interface{}) error {
+	return odie.ops().FindBy(v, column, value)
+}
+
+// GetBy will get the single record where column equals value
+func (odie *Odie) GetBy(column string, value interface{}, v interface{}) error {
+	return odie.ops().GetBy(column, value, v)
+}
+
+// Count will return the number of records in the table of the bean v
+func (odie *Odie) Count(v interface{}) (int64, error) {
+	return odie.ops().Count(v)
+}
+
+// CountWhere will return the number of records in the table of the bean v matching cond
+func (odie *Odie) CountWhere(v interface{}, cond builder.Cond) (int64, error) {
+	return odie.ops().CountWhere(v, cond)
+}
+
+// Distinct will return the sorted distinct values of column in the table of the bean v, such as for a filter select.
+// The column is checked against the table, so it is safe to take from user input
+func (odie *Odie) Distinct(v interface{}, column string) ([]string, error) {
+	return odie.ops().Distinct(v, column)
+}
+
+func (ops dbOps) DbInsert(v interface{}) error {
+	if err := ops.writable(); err != nil {
+		return err
+	}
+
+	affected, err := ops.q().Insert(v)
+	return expect("inserted", affected, 1, err, v)
+}
+func (ops dbOps) DbInsertMany(v interface{}) (int64, error) {
+	if err := ops.writable(); err != nil {
+		return 0, err
+	}
+	if k := reflect.Indirect(reflect.ValueOf(v)).Kind(); k != reflect.Slice {
+		return 0, fmt.Errorf("DbInsertMany: %T is not a slice", v)
+	}
+
+	return ops.q().Insert(v)
+}
+func (ops dbOps) DbGet(id int64, v interface{}) error {
+	if err := ops.configured(); err != nil {
+		return err
+	}
+
+	// has, err := ops.db.Where(Eq{"id": id}).Get(v)
+	has, err := ops.q().ID(id).Get(v)
+	return hasRecords(has, err, id)
+}
+func (ops dbOps) DbDelete(v interface{}) error {
+	if err := ops.writable(); err != nil {
+		return err
+	}
+
+	affected, err := ops.q().Unscoped().Delete(v)
+	return expect("deleted", affected, 1, err, v)
+}
+
+func (ops dbOps) DbSoftDelete(id int64, v interface{}) error {
+	if err := ops.writable(); err != nil {
+		return err
+	}
+
+	table := ops.engine.TableInfo(v)
+	if table.DeletedColumn() == nil {
+		return fmt.Errorf("No deleted column for table %s, tag a time field with xorm:\"deleted\"", table.Name)
+	}
+
+	// with a deleted column xorm's Delete is an update of the column
+	affected, err := ops.q().ID(id).Delete(v)
+	return expect("deleted", affected, 1, err, v)
+}
+func (ops dbOps) DbUpdate(id int64, v interface{}) error {
+	if err := ops.writable(); err != nil {
+		return err
+	}
+
+	affected, err := ops.q().ID(id).Update(v)
+	return expect("updated", affected, 1, err, v)
+}
+
+func (ops dbOps) DbUpsert(id int64, v interface{}) error {
+	if err := ops.writable(); err != nil {
+		return err
+	}
+	if ops.db != xorm.Interface(ops.engine) {
+		// already in a transaction
+		return upsert(ops.db, ops.engine.TableInfo(v), id, v)
+	}
+
+	session := ops.engine.NewSession()
+	defer session.Close()
+	if ops.ctx != nil {
+		session.Context(ops.ctx)
+	}
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if err := upsert(session, ops.engine.TableInfo(v), id, v); err != nil {
+		session.Rollback()
+		return err
+	}
+	return session.Commit()
+}
+
+// upsert sets the primary key of v to id, so an insert has the id, then updates the record if it exists.
+// The existence is checked, as MySQL reports no affected rows for an update which changes nothing
+func upsert(db xorm.Interface, table *xorm.Table, id int64, v interface{}) error {
+	pks := table.PKColumns()
+	if len(pks) != 1 {
+		return fmt.Errorf("Upsert: table %s needs a single primary key", table.Name)
+	}
+	pk, err := pks[0].ValueOf(v)
+	if err != nil {
+		return err
+	}
+	switch pk.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		pk.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		pk.SetUint(uint64(id))
+	default:
+		return fmt.Errorf("Upsert: primary key %s of table %s is not an int", pks[0].Name, table.Name)
+	}
+
+	exists, err := db.Table(v).Where(builder.Eq{pks[0].Name: id}).Exist()
+	if err != nil {
+		return err
+	}
+	if exists {
+		_, err := db.ID(id).Update(v)
+		return err
+	}
+
+	affected, err := db.Insert(v)
+	if err != nil {
+		return fmt.Errorf("Upsert of id %d found no record, and the insert failed: %w", id, err)
+	}
+	return expect("inserted", affected, 1, nil, v)
+}
+
+func (ops dbOps) DbUpdateCols(id int64, v interface{}, cols ...string) error {
+	if err := ops.writable(); err != nil {
+		return err
+	}
+	if len(cols) == 0 {
+		return fmt.Errorf("DbUpdateCols: no columns for %T", v)
+	}
+
+	table := ops.engine.TableInfo(v)
+	for _, col := range cols {
+		if table.GetColumn(col) == nil {
+			return fmt.Errorf("Unknown column %s for table %s", col, table.Name)
+		}
+	}
+
+	affected, err := ops.q().ID(id).Cols(cols...).Update(v)
+	return expect("updated", affected, 1, err, v)
+}
+
+func (ops dbOps) DbPatch(id int64, bean interface{}, changes map[string]interface{}) error {
+	if err := ops.writable(); err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	table := ops.engine.TableInfo(bean)
+	for col := range changes {
+		if table.GetColumn(col) == nil {
+			return fmt.Errorf("Unknown column %s for table %s", col, table.Name)
+		}
+	}
+
+	affected, err := ops.q().Table(bean).ID(id).Update(changes)
+	return expect("updated", affected, 1, err, changes)
+}
+
+func (ops dbOps) GetAll(v interface{}) error {
+	if err := ops.configured(); err != nil {
+		return err
+	}
+
+	return ops.q().Find(v)
+}
+
+func (ops dbOps) GetOrder(v interface{}, order string) error {
+	if err := ops.configured(); err != nil {
+		return err
+	}
+
+	return ops.q().OrderBy(order).Find(v)
+}
+
+func (ops dbOps) GetSorted(v interface{}, sorts ...Sort) error {
+	if err := ops.configured(); err != nil {
+		return err
+	}
+	if len(sorts) == 0 {
+		return ops.q().Find(v)
+	}
+
+	bean, err := sliceBean(v)
+	if err != nil {
+		return err
+	}
+	table := ops.engine.TableInfo(bean)
+
+	var session *xorm.Session
+	for _, s := range sorts {
+		if table.GetColumn(s.Column) == nil {
+			return fmt.Errorf("Unknown column %s for table %s", s.Column, table.Name)
+		}
+		switch {
+		case session == nil && s.Desc:
+			session = ops.q().Desc(s.Column)
+		case session == nil:
+			session = ops.q().Asc(s.Column)
+		case s.Desc:
+			session = session.Desc(s.Column)
+		default:
+			session = session.Asc(s.Column)
+		}
+	}
+	return session.Find(v)
+}
+
+func (ops dbOps) GetPage(v interface{}, page int, perPage int, order string) (int64, error) {
+	if err := ops.configured(); err != nil {
+		return 0, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+
+	bean, err := sliceBean(v)
+	if err != nil {
+		return 0, err
+	}
+	total, err := ops.q().Count(bean)
+	if err != nil {
+		return 0, err
+	}
+
+	session := ops.q().Limit(perPage, (page-1)*perPage)
+	if len(order) > 0 {
+		session = session.OrderBy(order)
+	}
+	return total, session.Find(v)
+}
+
+func (ops dbOps) Find(v interface{}, cond builder.Cond) error {
+	if err := ops.configured(); err != nil {
+		return err
+	}
+
+	return ops.q().Where(cond).Find(v)
+}
+
+func (ops dbOps) FindBy(v interface{}, column string, value interface{}) error {
+	return ops.Find(v, builder.Eq{column: value})
+}
+
+func (ops dbOps) GetBy(column string, value interface{}, v interface{}) error {
+	if err := ops.configured(); err != nil {
+		return err
+	}
+
+	has, err := ops.q().Where(builder.Eq{column: value}).Get(v)
+	return hasRecordsFor(has, err, fmt.Sprintf("%s %v", column, value))
+}
+
+func (ops dbOps) Count(v interface{}) (int64, error) {
+	if err := ops.configured(); err != nil {
+		return 0, err
+	}
+
+	return ops.q().Count(v)
+}
+
+func (ops dbOps) CountWhere(v interface{}, cond builder.Cond) (int64, error) {
+	if err := ops.configured(); err != nil {
+		return 0, err
+	}
+
+	return ops.q().Where(cond).Count(v)
+}
+
+func (ops dbOps) Distinct(v interface{}, column string) ([]string, error) {
+	if err := ops.configured(); err != nil {
+		return nil, err
+	}
+
+	table := ops.engine.TableInfo(v)
+	col := table.GetColumn(column)
+	if col == nil {
+		return nil, fmt.Errorf("Unknown column %s for table %s", column, table.Name)
+	}
+
+	session := ops.q().Table(v).Distinct(col.Name)
+	if deleted := table.DeletedColumn(); deleted != nil {
+		// a query by string does not exclude soft deleted records as the finders do
+		session = session.Where(ops.engine.CondDeleted(deleted.Name))
+	}
+	rows, err := session.QueryString()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row[col.Name])
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// sliceBean will return a new element of the slice pointed to by v, for use as a bean
+func sliceBean(v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%T is not a pointer to a slice", v)
+	}
+	t := rv.Elem().Type().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return reflect.New(t).Interface(), nil
+}
+
+func expect(what string, affected int64, expected int64, err error, i interface{}) error {
+	if err != nil {
+		return err
+	}
+	if affected != expected {
+		return fmt.Errorf("Expected %d rows %s, Got %d for record %+v", expected, what, affected, i)
+	}
+	return nil
+}
+func hasRecords(has bool, err error, id int64) error {
+	return hasRecordsFor(has, err, fmt.Sprintf("id %d", id))
+}
+func hasRecordsFor(has bool, err error, what string) error {
+	if err != nil {
+		return err
+	}
+	if !has {
+		return fmt.Errorf("No Records Found for %s: %w", what, NotFound)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package goodie
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// testItem is the model of the Db helper tests
+type testItem struct {
+	Id    int64
+	Name  string
+	Count int
+}
+
+// newTestDb returns an App with a sqlite3 database of testItems in a temporary directory.
+// It is a file, as each connection to :memory: has its own database
+func newTestDb(t *testing.T) *App {
+	t.Helper()
+	app := newTestServer().NewApp("app")
+	if err := app.SetDbDriver("sqlite3", filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("SetDbDriver: %s", err)
+	}
+	app.orm.ShowSQL(false)
+	if err := app.SyncModels(&testItem{}); err != nil {
+		t.Fatalf("SyncModels: %s", err)
+	}
+	t.Cleanup(func() { app.orm.Close() })
+	return app
+}
+
+// dbOdie returns an Odie with the database of app, as render would set it up
+func dbOdie(app *App) *Odie {
+	return &Odie{
+		Orm: app.orm,
+		app: app,
+	}
+}
+
+// itemNames returns the names of the testItems in the database, in id order
+func itemNames(t *testing.T, odie *Odie) string {
+	t.Helper()
+	var items []testItem
+	if err := odie.GetOrder(&items, "id"); err != nil {
+		t.Fatalf("GetOrder: %s", err)
+	}
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestDbOptionsMaxOpenConns(t *testing.T) {
+	tests := []struct {
+		max  int
+		want int
+	}{
+		{0, defaultMaxOpenConns},
+		{-1, defaultMaxOpenConns},
+		{1, 1},
+		{20, 20},
+	}
+	for _, tt := range tests {
+		app := newTestDb(t)
+		app.SetDbOptions(DbOptions{MaxOpenConns: tt.max})
+		if got := app.DbStats().MaxOpenConnections; got != tt.want {
+			t.Errorf("MaxOpenConns %d: MaxOpenConnections = %d, want %d", tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestDbUpdateCols(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int64
+		update  testItem
+		cols    []string
+		want    testItem
+		wantErr bool
+	}{
+		{"one column", 1, testItem{Name: "b", Count: 9}, []string{"name"}, testItem{Id: 1, Name: "b", Count: 5}, false},
+		{"zero value", 1, testItem{Name: "b"}, []string{"count"}, testItem{Id: 1, Name: "a", Count: 0}, false},
+		{"two columns", 1, testItem{Name: "b", Count: 9}, []string{"name", "count"}, testItem{Id: 1, Name: "b", Count: 9}, false},
+		{"unknown column", 1, testItem{Name: "b"}, []string{"nope"}, testItem{Id: 1, Name: "a", Count: 5}, true},
+		{"no columns", 1, testItem{Name: "b"}, nil, testItem{Id: 1, Name: "a", Count: 5}, true},
+		{"missing id", 2, testItem{Name: "b"}, []string{"name"}, testItem{Id: 1, Name: "a", Count: 5}, true},
+	}
+	for _, tt := range tests {
+		odie := dbOdie(newTestDb(t))
+		if err := odie.DbInsert(&testItem{Name: "a", Count: 5}); err != nil {
+			t.Fatalf("DbInsert: %s", err)
+		}
+
+		update := tt.update
+		err := odie.DbUpdateCols(tt.id, &update, tt.cols...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: DbUpdateCols = %v, want error %t", tt.name, err, tt.wantErr)
+		}
+		var got testItem
+		if err := odie.DbGet(1, &got); err != nil {
+			t.Fatalf("%s: DbGet: %s", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: item = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDbUpdateColsReadOnly(t *testing.T) {
+	app := newTestDb(t)
+	odie := dbOdie(app)
+	if err := odie.DbInsert(&testItem{Name: "a"}); err != nil {
+		t.Fatalf("DbInsert: %s", err)
+	}
+	app.ReadOnly = true
+	if err := odie.DbUpdateCols(1, &testItem{Name: "b"}, "name"); !errors.Is(err, ReadOnlyError) {
+		t.Errorf("DbUpdateCols of a ReadOnly App = %v, want %v", err, ReadOnlyError)
+	}
+}
+
+func TestDbUpsert(t *testing.T) {
+	odie := dbOdie(newTestDb(t))
+	if err := odie.DbInsert(&testItem{Name: "first"}); err != nil {
+		t.Fatalf("DbInsert: %s", err)
+	}
+
+	steps := []struct {
+		item testItem
+		want string
+	}{
+		{testItem{Name: "a", Count: 1}, "first a"},
+		// the same id again updates the record
+		{testItem{Name: "b", Count: 2}, "first b"},
+		// an update which changes nothing is not an insert
+		{testItem{Name: "b", Count: 2}, "first b"},
+	}
+	for i, step := range steps {
+		item := step.item
+		if err := odie.DbUpsert(7, &item); err != nil {
+			t.Fatalf("%d: DbUpsert: %s", i, err)
+		}
+		if item.Id != 7 {
+			t.Errorf("%d: DbUpsert id = %d, want 7", i, item.Id)
+		}
+		if got := itemNames(t, odie); got != step.want {
+			t.Errorf("%d: items = %q, want %q", i, got, step.want)
+		}
+	}
+
+	var got testItem
+	if err := odie.DbGet(7, &got); err != nil {
+		t.Fatalf("DbGet: %s", err)
+	}
+	if got != (testItem{Id: 7, Name: "b", Count: 2}) {
+		t.Errorf("item 7 = %+v", got)
+	}
+	if n, err := odie.Count(&testItem{}); err != nil || n != 2 {
+		t.Errorf("Count = %d, %v, want 2", n, err)
+	}
+}
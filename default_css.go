@@ -12,6 +12,19 @@ var default_css = `
 .goodieerror {
     color: red;
 }
+.goodieflash {
+    padding: 4px;
+}
+.goodieflash-info {
+    background: lightblue;
+}
+.goodieflash-success {
+    background: lightgreen;
+}
+.goodieflash-error {
+    background: pink;
+    color: red;
+}
 
 table.goodietable {
 
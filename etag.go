@@ -0,0 +1,56 @@
+package goodie
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SetETag turns on the ETag for the rendered page.  The page is cached by the browser, which revalidates it
+// on each request, and gets a 304 Not Modified when the page has not changed.  Leave it off for pages showing live data
+func (odie *Odie) SetETag(on bool) {
+	odie.etag = on
+}
+
+// writeETagDocument will write the document with an ETag of its content, or a 304 if the request already has it
+func (odie *Odie) writeETagDocument() {
+	var buf bytes.Buffer
+	odie.Doc.IoRender(&buf)
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	h := odie.Response.Header()
+	if !odie.cacheSet {
+		h.Del("Expires")
+		h.Set("Cache-Control", "no-cache")
+	}
+	h.Set("ETag", etag)
+
+	ok := odie.status == 0 || odie.status == http.StatusOK
+	method := odie.Request.Method
+	if ok && (method == http.MethodGet || method == http.MethodHead) && etagMatch(odie.Request.Header.Get("If-None-Match"), etag) {
+		odie.Response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	if odie.status != 0 {
+		odie.Response.WriteHeader(odie.status)
+	}
+	odie.Response.Write(buf.Bytes())
+}
+
+// etagMatch checks if the If-None-Match header has etag, weak tags match
+func etagMatch(ifNoneMatch string, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package goodie
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var tailPoll = 500 * time.Millisecond
+
+// safePath will join file onto base, returning an error if file tries to traverse out of base with ..
+func safePath(base string, file string) (string, error) {
+	for _, elem := range strings.FieldsFunc(file, isSlash) {
+		if elem == ".." {
+			return "", fmt.Errorf("Invalid path: %s", file)
+		}
+	}
+	return filepath.Join(base, filepath.FromSlash(path.Clean("/"+file))), nil
+}
+
+func isSlash(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+// TailFile will stream a file relative to the application's base directory to the response.
+// If follow is true, data appended to the file will continue to be streamed until the client disconnects.
+// The response is written directly, so the page is not rendered after it.
+// Note that the server WriteTimeout will limit how long a file can be followed
+func (odie *Odie) TailFile(file string, follow bool) error {
+	p, err := safePath(odie.Path, file)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	odie.Response.Header().Set("Content-type", "text/plain; charset=utf-8")
+	odie.Response.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher, _ := odie.Response.(http.Flusher)
+	odie.committed = true
+
+	ctx := odie.Request.Context()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if _, werr := odie.Response.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		// at end of file
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if !follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(tailPoll):
+		}
+	}
+}
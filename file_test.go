@@ -0,0 +1,38 @@
+package goodie
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+func TestTailFileCommits(t *testing.T) {
+	s := newTestServer()
+	home := t.TempDir()
+	s.SetHome(home)
+	if err := os.Mkdir(filepath.Join(home, "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "app", "log.txt"), []byte("line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app := s.NewApp("app")
+	app.Register("tail", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, []byte("not sent"), p.TailFile("log.txt", false)
+	}))
+	app.Register("missing", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, p.TailFile("missing.txt", false)
+	}))
+
+	rec := serveTest(s, "GET", "/app/tail", "")
+	if rec.Code != 200 || rec.Body.String() != "line\n" {
+		t.Errorf("TailFile = %d %q, want 200 %q", rec.Code, rec.Body.String(), "line\n")
+	}
+	rec = serveTest(s, "GET", "/app/missing", "")
+	if !strings.Contains(rec.Body.String(), "goodieerror") {
+		t.Errorf("TailFile of a missing file = %q, want the error page", rec.Body.String())
+	}
+}
@@ -0,0 +1,66 @@
+package goodie
+
+import (
+	"encoding/json"
+
+	"github.com/debspencer/html"
+)
+
+// Flash message levels, each has a goodieflash-<level> CSS class
+const (
+	FlashInfo    = "info"
+	FlashSuccess = "success"
+	FlashError   = "error"
+)
+
+var flashKey = "_flash"
+
+type flash struct {
+	Level   string `json:"l"`
+	Message string `json:"m"`
+}
+
+// Flash will keep a message in the session, to be shown on the next page displayed, such as after an Action's refresh.
+// level is FlashInfo, FlashSuccess or FlashError.  The Server secret must be set
+func (odie *Odie) Flash(level string, message string) error {
+	session := odie.Session()
+	flashes := append(loadFlashes(session), flash{Level: level, Message: message})
+	data, err := json.Marshal(flashes)
+	if err != nil {
+		return err
+	}
+	session.Set(flashKey, string(data))
+	return session.Save()
+}
+
+func loadFlashes(session *Session) []flash {
+	var flashes []flash
+	if data := session.Get(flashKey); len(data) > 0 {
+		json.Unmarshal([]byte(data), &flashes)
+	}
+	return flashes
+}
+
+// showFlashes will add the flash messages to the body, and clear them from the session
+func (odie *Odie) showFlashes() {
+	server := odie.server()
+	if server == nil || len(server.secret) == 0 {
+		return
+	}
+
+	session := odie.Session()
+	flashes := loadFlashes(session)
+	if len(flashes) == 0 {
+		return
+	}
+	session.Delete(flashKey)
+	session.Save()
+
+	for _, f := range flashes {
+		div := html.Div()
+		// AddClassName replaces the class, so both are set at once
+		div.AddClassName("goodieflash goodieflash-" + f.Level)
+		div.Add(html.Text(f.Message))
+		odie.Body.Add(div)
+	}
+}
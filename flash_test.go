@@ -0,0 +1,90 @@
+package goodie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+// sessionCookie returns the session cookie set by rec, or the one sent before if it did not set one
+func sessionCookie(t *testing.T, rec *httptest.ResponseRecorder, sent *http.Cookie) *http.Cookie {
+	t.Helper()
+	var set []*http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == defaultSessionCookie {
+			set = append(set, c)
+		}
+	}
+	switch len(set) {
+	case 0:
+		return sent
+	case 1:
+		return set[0]
+	}
+	t.Fatalf("%d session cookies set", len(set))
+	return nil
+}
+
+func TestFlash(t *testing.T) {
+	s := newTestServer()
+	s.SetSecret([]byte("secret"))
+	app := s.NewApp("app")
+	app.Register("save", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		if err := p.Flash(FlashSuccess, "Saved <b>"); err != nil {
+			return nil, nil, err
+		}
+		if err := p.Flash(FlashError, "Check the name"); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, p.RedirectPath("/app/list", http.StatusSeeOther)
+	}))
+	app.Register("list", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, nil
+	}))
+
+	var cookie *http.Cookie
+	get := func(target string) string {
+		req := httptest.NewRequest("GET", target, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := serveRequest(s, req)
+		cookie = sessionCookie(t, rec, cookie)
+		return rec.Body.String()
+	}
+
+	get("/app/save")
+	if cookie == nil {
+		t.Fatal("Flash did not set the session cookie")
+	}
+
+	body := get("/app/list")
+	for _, want := range []string{`class="goodieflash goodieflash-success"`, "Saved &lt;b&gt;", `class="goodieflash goodieflash-error"`, "Check the name"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page after Flash does not have %q: %s", want, body)
+		}
+	}
+	if strings.Index(body, "Saved") > strings.Index(body, "Check the name") {
+		t.Errorf("flashes are not in the order added: %s", body)
+	}
+
+	if body := get("/app/list"); strings.Contains(body, `class="goodieflash`) {
+		t.Errorf("flash shown twice: %s", body)
+	}
+}
+
+func TestFlashWithoutSecret(t *testing.T) {
+	s := newTestServer()
+	var err error
+	s.NewApp("app").Register("save", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		err = p.Flash(FlashInfo, "hello")
+		return nil, nil, nil
+	}))
+	serveTest(s, "GET", "/app/save", "")
+	if err == nil {
+		t.Errorf("Flash without a secret did not fail")
+	}
+}
@@ -0,0 +1,53 @@
+package goodie
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var timeDisplay = "2006-01-02 15:04:05"
+
+// FormatValue will return v as a string suitable for display.
+// Null values, such as an invalid sql.NullInt64 or a nil pointer, are displayed as the Server's NullDisplay
+func (odie *Odie) FormatValue(v interface{}) string {
+	return formatValue(v, odie.app.odie.NullDisplay)
+}
+
+// formatValue is the null aware stringification used by all rendering helpers
+func formatValue(v interface{}, null string) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return null
+		}
+		dv, err := valuer.Value()
+		if err != nil {
+			return null
+		}
+		v = dv
+	}
+
+	switch t := v.(type) {
+	case nil:
+		return null
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.Format(timeDisplay)
+	case fmt.Stringer:
+		return t.String()
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return null
+		}
+		return formatValue(rv.Elem().Interface(), null)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -1,9 +1,11 @@
 module github.com/debspencer/goodie
 
-go 1.16
+go 1.19
 
 require (
 	github.com/debspencer/html v0.0.0-20210619175955-fc4133eb39e8
 	github.com/go-xorm/xorm v0.7.9
+	github.com/mattn/go-sqlite3 v1.10.0
+	xorm.io/builder v0.3.6
 	xorm.io/core v0.7.3
 )
@@ -1,29 +1,61 @@
 package goodie
 
 import (
-	"database/sql"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
-	"reflect"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/debspencer/html"
 	"github.com/go-xorm/xorm"
-	"xorm.io/core"
 )
 
 var (
-	defaultReadTimeout    = 10 * time.Second
-	defaultWriteTimeout   = 10 * time.Second
-	defaultMaxHeaderBytes = 16 * 1024
+	defaultReadTimeout     = 10 * time.Second
+	defaultWriteTimeout    = 10 * time.Second
+	defaultShutdownTimeout = 10 * time.Second
+	defaultMaxHeaderBytes  = 16 * 1024
+	defaultMaxOpenConns    = 5
+
+	faviconTypes = map[string]string{
+		".ico": "image/x-icon",
+		".png": "image/png",
+		".svg": "image/svg+xml",
+	}
+
+	defaultBreadcrumbRoot      = "Home"
+	defaultBreadcrumbSeparator = ">"
 
 	NotFound    = errors.New("Not Found")
 	ServerError = errors.New("Internal Server Error")
+
+	// Handled can be returned by Init or Action when the handler has written the response itself,
+	// such as a redirect.  No document or error will be rendered
+	Handled = errors.New("Handled")
+
+	// ReadOnlyError is returned by the Db write helpers when the App is ReadOnly
+	ReadOnlyError = errors.New("Read Only")
+
+	// InvalidCSRF is returned when an action has a missing or invalid CSRF token, it is rendered with a 403 status
+	InvalidCSRF = errors.New("Invalid CSRF Token")
+
+	// DbBusy is returned by the Db helpers when no connection is free within DbOptions.AcquireTimeout,
+	// it is rendered with a 503 status
+	DbBusy = errors.New("Database Busy")
+
+	// RequestTooLarge is returned when a request body is over its limit, it is rendered with a 413 status
+	RequestTooLarge = errors.New("Request Entity Too Large")
 )
 
 type NewHandler func() Handler
@@ -33,20 +65,82 @@ type Server struct {
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	MaxHeaderBytes int
-
-	handlers map[string]AppHandler
-	favicon  []byte
-	home     string
-}
+	NullDisplay    string     // displayed for null sql values, such as an invalid sql.NullString
+	OnTiming       TimingFunc // optional, called with the phase timing of each rendered page
+	MaxBodyBytes   int64      // limit of a request body, 0 is unlimited.  A larger body is a 413
+
+	// ShutdownTimeout is how long RunContext waits for in flight requests when ctx is cancelled
+	ShutdownTimeout time.Duration
+
+	routes
+	hosts         map[string]*Host
+	wildcardHosts []*Host
+
+	statics     []*staticRoute
+	assets      fs.FS // static dirs and favicon files are read from here when set
+	middleware  []Middleware
+	favicon     []byte
+	faviconType string
+	home        string
+	apps        []*App
+	serverMu    sync.Mutex
+	server      *http.Server
+
+	notFoundHandler http.HandlerFunc
+	notFoundPage    *AppHandler // rendered with a 404 status in place of notFoundHandler
+	errorRenderer   ErrorRenderer
+	logger          Logger
+	trustedProxies  []*net.IPNet
+	healthPath      string
+	cacheControl    string
+	mimeTypes       map[string]string
+	cors            *CORSOptions
+	metrics         *metrics
+	trailingSlash   TrailingSlash
+
+	paramConstraints map[string]ParamConstraint
+
+	secret        []byte
+	sessionKey    []byte // derived from secret, signs only session cookies
+	sessionCookie string
+	sessionMaxAge time.Duration
+}
+
+// ErrorRenderer renders the error page for a failed request
+type ErrorRenderer func(odie *Odie, err error)
 
 type App struct {
-	odie *Server
-	name string
-	orm  *xorm.Engine
+	// AutoParseForm controls if the request form is parsed before Init is called, defaults to true.
+	// Turn off for handlers which need to consume the request body themselves, then call Odie.ParseForm if needed
+	AutoParseForm bool
+
+	// ReadOnly will reject all writes by the Db helpers with ReadOnlyError, reads are allowed
+	ReadOnly bool
+
+	// CheckCSRF will verify the CSRF token added by NewForm before every action, except PublicActions,
+	// and before every _method override.
+	// The Server secret must be set
+	CheckCSRF bool
+
+	odie       *Server
+	host       *Host // the App only serves requests to host, if set
+	name       string
+	mount      string // URL prefix of the App's pages, defaults to /name
+	orm        *xorm.Engine
+	dbOptions  DbOptions
+	middleware []OdieMiddleware
+
+	breadcrumbRoot      string
+	breadcrumbSeparator string
+
+	publicActions map[string]bool
+	defaultQuery  url.Values
+	routeNames    map[string]string // page pattern of each RegisterNamed route, including the mount
 }
 
 type Handler interface {
 	render(app *App, w http.ResponseWriter, req *http.Request, handler Handler) // implemented by Odie
+	getOdie() *Odie
 
 	// Init App.  Returns slice of urls showing stack (index -> page1 -> page2)
 	// Optional, if []byte is returned, then that data is written
@@ -61,26 +155,93 @@ type Handler interface {
 func Init(addr string, o *Server) *Server {
 	if o == nil {
 		o = &Server{
-			ReadTimeout:    defaultReadTimeout,
-			WriteTimeout:   defaultWriteTimeout,
-			MaxHeaderBytes: defaultMaxHeaderBytes,
+			ReadTimeout:     defaultReadTimeout,
+			WriteTimeout:    defaultWriteTimeout,
+			ShutdownTimeout: defaultShutdownTimeout,
+			MaxHeaderBytes:  defaultMaxHeaderBytes,
 		}
 		o.SetHome(os.Getenv("GOODIE_HOME"))
 	}
 	o.Addr = addr
-	o.handlers = make(map[string]AppHandler)
+	o.handlers = make(map[string]*route)
+	if len(o.sessionCookie) == 0 {
+		o.sessionCookie = defaultSessionCookie
+	}
+	if o.sessionMaxAge == 0 {
+		o.sessionMaxAge = defaultSessionMaxAge
+	}
 	return o
 }
 
 func (s *Server) NewApp(name string) *App {
-	return &App{
-		odie: s,
-		name: name,
+	app := &App{
+		AutoParseForm: true,
+		dbOptions: DbOptions{
+			MaxOpenConns: defaultMaxOpenConns,
+			ShowSQL:      true,
+		},
+		odie:                s,
+		name:                name,
+		mount:               normalizeMount(name),
+		breadcrumbRoot:      defaultBreadcrumbRoot,
+		breadcrumbSeparator: defaultBreadcrumbSeparator,
+	}
+	s.apps = append(s.apps, app)
+	return app
+}
+
+// NewAppAt will create an App whose pages are under prefix, such as /api/v1, rather than /name
+func (s *Server) NewAppAt(name string, prefix string) *App {
+	app := s.NewApp(name)
+	app.SetMount(prefix)
+	return app
+}
+
+// SetMount sets the URL prefix of the App's pages, such as /api/v1.  Pages registered after are under the prefix,
+// / mounts the App at the root
+func (a *App) SetMount(prefix string) {
+	a.mount = normalizeMount(prefix)
+}
+
+// Mount returns the URL prefix of the App's pages
+func (a *App) Mount() string {
+	return a.mount
+}
+
+// normalizeMount will give prefix a leading and no trailing slash, the root is empty
+func normalizeMount(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if len(prefix) == 0 {
+		return ""
 	}
+	return "/" + prefix
 }
 
 func (s *Server) AddFavicon(favicon []byte) {
 	s.favicon = favicon
+	s.faviconType = ""
+}
+
+// AddFaviconFile will read the favicon from file, relative to the server home or the asset FS if one has been set.
+// The content type is from the extension, .ico, .png or .svg
+func (s *Server) AddFaviconFile(file string) error {
+	var data []byte
+	var err error
+	if s.assets != nil {
+		data, err = fs.ReadFile(s.assets, strings.TrimPrefix(path.Clean("/"+file), "/"))
+	} else {
+		data, err = os.ReadFile(s.Path(file))
+	}
+	if err != nil {
+		return err
+	}
+
+	s.favicon = data
+	s.faviconType = faviconTypes[strings.ToLower(filepath.Ext(file))]
+	if len(s.faviconType) == 0 {
+		s.faviconType = http.DetectContentType(data)
+	}
+	return nil
 }
 
 func (s *Server) SetHome(home string) {
@@ -91,39 +252,61 @@ func (s *Server) Path(file string) string {
 	return path.Join(s.home, file)
 }
 
-func (a *App) SetDb(db string) error {
-	db = a.odie.Path(db)
-	fmt.Println("SetDB", db)
-	orm, err := xorm.NewEngine("sqlite3", db)
+// SetNotFoundHandler will set the handler called when no page or file matches the request
+func (s *Server) SetNotFoundHandler(h func(w http.ResponseWriter, req *http.Request)) {
+	s.notFoundHandler = h
+	s.notFoundPage = nil
+}
 
-	if err != nil {
-		return err
+// RegisterNotFound will render the page of h in the App, with a 404 Not Found status, when no page or file
+// matches the request, in place of any SetNotFoundHandler.  The page has the App's header, footer and links
+func (a *App) RegisterNotFound(h NewHandler) {
+	a.odie.notFoundPage = &AppHandler{
+		handler: h,
+		app:     a,
 	}
+	a.odie.notFoundHandler = nil
+}
 
-	orm.SetColumnMapper(core.SnakeMapper{})
-	orm.SetMaxOpenConns(5)
-	//	orm.SetLogger(&logger{})
-	orm.ShowSQL(true)
-	a.orm = orm
-
-	return nil
+// SetErrorRenderer will set the renderer used by Odie.RenderError for every app
+func (s *Server) SetErrorRenderer(r ErrorRenderer) {
+	s.errorRenderer = r
 }
 
-type AppHandler struct {
-	handler NewHandler
-	app     *App
+// SetDefaultQuery sets a query value that Odie.Url has when the request does not have key.
+// An empty value for key in the request overrides the default
+func (a *App) SetDefaultQuery(key string, value string) {
+	if a.defaultQuery == nil {
+		a.defaultQuery = make(url.Values)
+	}
+	a.defaultQuery.Set(key, value)
 }
 
-func (a *App) Register(page string, h NewHandler) {
-	if len(page) > 0 && !strings.HasPrefix(page, "/") {
-		page = "/" + page
+// withDefaultQuery returns a copy of values with the default query added for missing keys
+func (a *App) withDefaultQuery(values url.Values) url.Values {
+	if len(a.defaultQuery) == 0 {
+		return values
 	}
-	page = "/" + a.name + page
-	fmt.Println("Register:", page)
-	a.odie.handlers[page] = AppHandler{
-		handler: h,
-		app:     a,
+	q := make(url.Values, len(values)+len(a.defaultQuery))
+	for k, vs := range values {
+		q[k] = vs
 	}
+	for k, vs := range a.defaultQuery {
+		if _, ok := q[k]; !ok {
+			q[k] = vs
+		}
+	}
+	return q
+}
+
+// SetBreadcrumbRoot sets the label of the HomeURL, defaults to Home
+func (a *App) SetBreadcrumbRoot(label string) {
+	a.breadcrumbRoot = label
+}
+
+// SetBreadcrumbSeparator sets the separator between urls in the header and footer, defaults to >
+func (a *App) SetBreadcrumbSeparator(sep string) {
+	a.breadcrumbSeparator = sep
 }
 
 func (a *App) Path(element string) string {
@@ -131,36 +314,88 @@ func (a *App) Path(element string) string {
 }
 
 func (o *Server) Run() error {
-	s := &http.Server{
+	return o.httpServer().ListenAndServe()
+}
+
+// RunTLS will run an HTTPS server.  Relative cert and key files are relative to the server home
+func (o *Server) RunTLS(certFile string, keyFile string) error {
+	return o.httpServer().ListenAndServeTLS(o.homePath(certFile), o.homePath(keyFile))
+}
+
+// homePath will resolve a relative file under the server home
+func (o *Server) homePath(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return o.Path(file)
+}
+
+// RunContext will run the server until ctx is cancelled, then Shutdown the server, waiting up to ShutdownTimeout
+func (o *Server) RunContext(ctx context.Context) error {
+	s := o.httpServer()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := o.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	sctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := o.Shutdown(sctx)
+	<-errc
+	return err
+}
+
+// Shutdown will gracefully stop the server, waiting for in flight requests, then close each App's database
+func (o *Server) Shutdown(ctx context.Context) error {
+	o.serverMu.Lock()
+	server := o.server
+	o.serverMu.Unlock()
+
+	var err error
+	if server != nil {
+		err = server.Shutdown(ctx)
+	}
+
+	for _, app := range o.apps {
+		if app.orm != nil {
+			if cerr := app.orm.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+func (o *Server) httpServer() *http.Server {
+	o.serverMu.Lock()
+	defer o.serverMu.Unlock()
+	o.server = &http.Server{
 		Addr:           o.Addr,
 		Handler:        o,
 		ReadTimeout:    o.ReadTimeout,
 		WriteTimeout:   o.WriteTimeout,
 		MaxHeaderBytes: o.MaxHeaderBytes,
 	}
-	return s.ListenAndServe()
+	return o.server
 }
 
-func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	path := req.URL.Path
-	fmt.Println("Request:", path, req.URL.RawQuery)
-	appHandler, ok := s.handlers[path]
-	if !ok {
-		if path == "/favicon.ico" && len(s.favicon) > 0 {
-			s.showFavicon(w)
-			return
-		}
-
-		fmt.Printf("404 = '%s'\n", req.URL.Path)
-		w.WriteHeader(404)
-		return
-	}
-
-	handler := appHandler.handler()
-	handler.render(appHandler.app, w, req, handler)
-}
 func (s *Server) showFavicon(w http.ResponseWriter) {
-	w.Header().Add("Content-type", "image/x-icon")
+	ct := s.faviconType
+	if len(ct) == 0 {
+		ct = "image/x-icon"
+	}
+	w.Header().Add("Content-type", ct)
 	w.Write(s.favicon)
 }
 
@@ -172,40 +407,115 @@ type Odie struct {
 	Url        *html.URL
 	Orm        *xorm.Engine
 	Path       string // Path to applicatio's base directory
+	AppName    string // name of the App serving the request
+	RouteName  string // registered page pattern that matched, including the mount, such as /blog/post/:id, empty for a matcher
 	defaultUrl *html.URL
+	app        *App
+	params     map[string]string
+	committed  bool // response has been written, render will not write a document
+	timing     Timing
+	lapStart   time.Time
+	values     map[string]interface{}
+	session    *Session
+	renderErr  error // error rendered by the lifecycle
+	status     int   // set by SetStatus
+	etag       bool  // set by SetETag
+	cacheSet   bool  // Cache-Control is not the no-cache default
+
+	breadcrumbs *Breadcrumbs
+	parseErr    error     // from parsing the form before Init
+	response    *Response // set by Respond
 }
 
 // Render will create an HTML docuement and render the page
 func (odie *Odie) render(app *App, w http.ResponseWriter, req *http.Request, handler Handler) {
 	odie.Request = req
-	odie.Response = w
+	odie.Response = &pageWriter{ResponseWriter: w}
+	odie.app = app
 
-	w.Header().Add("Expires", "Sat, Jan 1 2000 00:00:00 GMT")
-	w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate")
+	odie.lapStart = time.Now()
+	defer odie.reportTiming(odie.lapStart)
 
-	req.ParseForm()
-	odie.Url = html.NewURL(req.URL, req.Form)
+	odie.setCacheHeaders()
+
+	if app.AutoParseForm {
+		if err := bodyError(req.ParseForm()); errors.Is(err, RequestTooLarge) {
+			odie.parseErr = err
+		}
+		odie.Url = html.NewURL(req.URL, app.withDefaultQuery(req.Form))
+	} else {
+		odie.Url = html.NewURL(req.URL, app.withDefaultQuery(req.URL.Query()))
+	}
+	odie.params, _ = req.Context().Value(paramsKey{}).(map[string]string)
+	if req.Context().Value(notFoundKey{}) != nil {
+		odie.status = http.StatusNotFound
+	}
+	odie.lap(&odie.timing.Parse)
 
 	odie.Orm = app.orm
 	odie.Path = app.Path(app.name)
+	odie.AppName = app.name
+	odie.RouteName, _ = req.Context().Value(routeKey{}).(string)
 
 	// create the HTML doc, but don't add a body to it yet
-	odie.Doc = html.NewDocument()
-	odie.Doc.AddCSS(html.CSS(default_css))
+	odie.Doc = newDocument()
+
+	// run the handler inside the app's odie middleware, then the group's
+	next := OdieHandler(func(odie *Odie) {
+		odie.lifecycle(handler)
+	})
+	middleware := groupMiddleware(app, req)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	next(odie)
+}
+
+// lifecycle will call each of the handler's methods to render the page
+func (odie *Odie) lifecycle(handler Handler) {
+	if odie.parseErr != nil {
+		odie.renderError(odie.parseErr)
+		return
+	}
+
+	// a _method override changes state, so it needs a CSRF token as an action does
+	if err := odie.checkOverrideCSRF(); err != nil {
+		odie.renderError(err)
+		return
+	}
 
 	// call handler's init method.  It will return the base named.
 	urls, data, err := handler.Init()
+	odie.lap(&odie.timing.Init)
+	if err == Handled {
+		odie.committed = true
+		return
+	}
 	if err != nil {
-		odie.RenderError(err)
+		odie.renderError(err)
+		return
+	}
+	if odie.committed {
 		return
 	}
 
+	if odie.response != nil {
+		odie.writeResponse(odie.response)
+		return
+	}
 	if data != nil {
+		odie.Response.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if odie.status != 0 {
+			odie.Response.WriteHeader(odie.status)
+		}
 		odie.Response.Write(data)
 		return
 	}
 
 	// urls will be a stacked list of urls for the header.  The last url will be the current page
+	if odie.breadcrumbs != nil && len(odie.breadcrumbs.urls) > 0 {
+		urls = odie.breadcrumbs.urls
+	}
 	var topurl *html.URL
 	if len(urls) > 0 {
 		topurl = urls[len(urls)-1]
@@ -219,10 +529,23 @@ func (odie *Odie) render(app *App, w http.ResponseWriter, req *http.Request, han
 	// if there is an action query string, the perform the action
 	action := odie.Url.GetQuery("action")
 	if len(action) > 0 {
+		if err := odie.checkActionCSRF(action); err != nil {
+			odie.renderError(err)
+			return
+		}
+
 		refreshUrl, err := handler.Action(action)
+		odie.lap(&odie.timing.Action)
 
+		if err == Handled {
+			odie.committed = true
+			return
+		}
 		if err != nil {
-			odie.RenderError(err)
+			odie.renderError(err)
+			return
+		}
+		if odie.committed {
 			return
 		}
 
@@ -231,7 +554,7 @@ func (odie *Odie) render(app *App, w http.ResponseWriter, req *http.Request, han
 			refreshUrl.DelQuery("action") // remove action so we don't go into an infinite loop
 
 			odie.Doc.Head().Add(html.MetaRefresh(0, refreshUrl.Link()))
-			odie.Doc.Render(odie.Response)
+			writeDocument(odie.Response, odie.Doc, 0)
 			return
 		}
 	}
@@ -240,17 +563,6 @@ func (odie *Odie) render(app *App, w http.ResponseWriter, req *http.Request, han
 	odie.Body = odie.Doc.Body()
 	odie.Body.AddClassName("goodiebody")
 
-	if err != nil {
-		if err == NotFound {
-			w.WriteHeader(404)
-			return
-		}
-		if err == ServerError {
-			w.WriteHeader(500)
-			return
-		}
-	}
-
 	// Set a default title if init did not do so
 	title := odie.Doc.Head().GetTitle()
 	if len(title) == 0 && topurl != nil {
@@ -258,10 +570,235 @@ func (odie *Odie) render(app *App, w http.ResponseWriter, req *http.Request, han
 	}
 
 	handler.Header(urls)
+	odie.showFlashes()
 	handler.Display()
 	handler.Footer(urls)
+	odie.lap(&odie.timing.Display)
+
+	if odie.committed {
+		return
+	}
+	if odie.etag {
+		odie.writeETagDocument()
+	} else {
+		writeDocument(odie.Response, odie.Doc, odie.status)
+	}
+	odie.lap(&odie.timing.Render)
+}
+
+// renderError will set the status for the known errors, then render the error
+func (odie *Odie) renderError(err error) {
+	odie.renderErr = err
+	switch {
+	case errors.Is(err, NotFound):
+		odie.errorStatus(http.StatusNotFound)
+	case errors.Is(err, ServerError):
+		odie.errorStatus(http.StatusInternalServerError)
+	case errors.Is(err, ReadOnlyError), errors.Is(err, InvalidCSRF):
+		odie.errorStatus(http.StatusForbidden)
+	case errors.Is(err, RequestTooLarge):
+		odie.errorStatus(http.StatusRequestEntityTooLarge)
+	case errors.Is(err, DbBusy):
+		odie.errorStatus(http.StatusServiceUnavailable)
+	}
+	odie.RenderError(err)
+}
+
+// errorStatus sets the status of the error page about to be rendered.  It is written with the first write of the
+// page, so RenderError can still set headers such as Content-Length
+func (odie *Odie) errorStatus(status int) {
+	if pw, ok := odie.Response.(*pageWriter); ok {
+		pw.status = status
+		return
+	}
+	odie.Response.WriteHeader(status)
+}
+
+// pageWriter is the ResponseWriter of a page.  An error status set by errorStatus waits for the first write
+type pageWriter struct {
+	http.ResponseWriter
+	status      int // written with the first write, 0 is 200
+	wroteHeader bool
+}
+
+func (w *pageWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest {
+		noStoreHeaders(w.Header())
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *pageWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader && w.status != 0 {
+		w.WriteHeader(w.status)
+	}
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush keeps streaming responses such as TailFile working
+func (w *pageWriter) Flush() {
+	if !w.wroteHeader && w.status != 0 {
+		w.WriteHeader(w.status)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to reach the underlying ResponseWriter
+func (w *pageWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
 
-	odie.Doc.Render(odie.Response)
+// Redirect will write a Location header for u with the 3xx code, defaulting to 302 Found.
+// No document will be rendered.  Returns Handled, so Init or Action can return it, such as
+// return nil, odie.Redirect(u, 0) from an Action instead of returning a refresh URL
+func (odie *Odie) Redirect(u *html.URL, code int) error {
+	return odie.RedirectPath(u.Link(), code)
+}
+
+// RedirectPath is Redirect for a plain path or URL string
+func (odie *Odie) RedirectPath(path string, code int) error {
+	if code < 300 || code > 399 {
+		code = http.StatusFound
+	}
+	http.Redirect(odie.Response, odie.Request, path, code)
+	odie.committed = true
+	return Handled
+}
+
+// Context returns the context of the request, it is cancelled when the client goes away.
+// The Db helpers use it, so their queries stop with the request
+func (odie *Odie) Context() context.Context {
+	return odie.Request.Context()
+}
+
+// Response is a complete response, see Respond
+type Response struct {
+	Status int // defaults to 200 OK
+	Header http.Header
+	Body   []byte
+}
+
+// Respond will have the response written in place of the page when Init returns.  It takes precedence over
+// data returned by Init, and no action or document is rendered.  An error returned by Init is rendered instead
+func (odie *Odie) Respond(r *Response) {
+	odie.response = r
+}
+
+func (odie *Odie) writeResponse(r *Response) {
+	h := odie.Response.Header()
+	for k, vs := range r.Header {
+		h[k] = vs
+	}
+	h.Set("Content-Length", strconv.Itoa(len(r.Body)))
+	if r.Status != 0 {
+		odie.Response.WriteHeader(r.Status)
+	}
+	odie.Response.Write(r.Body)
+	odie.committed = true
+}
+
+// SetStatus sets the HTTP status of the rendered page or data, defaults to 200 OK.
+// It does not change the status of errors, redirects or JSON responses, which write their own
+func (odie *Odie) SetStatus(code int) {
+	odie.status = code
+}
+
+// NoContent will write a 204 No Content status, no document will be rendered
+func (odie *Odie) NoContent() {
+	odie.Response.WriteHeader(http.StatusNoContent)
+	odie.committed = true
+}
+
+func (odie *Odie) getOdie() *Odie {
+	return odie
+}
+
+// writeDocument will render the document into a buffer, so the Content-Length is known and the
+// connection can be reused, then write it.  A status of 0 is 200 OK
+func writeDocument(w http.ResponseWriter, doc *html.Document, status int) {
+	var buf bytes.Buffer
+	doc.IoRender(&buf)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	if status != 0 {
+		w.WriteHeader(status)
+	}
+	w.Write(buf.Bytes())
+}
+
+// newDocument will create an HTML document with the default CSS
+func newDocument() *html.Document {
+	doc := html.NewDocument()
+	doc.AddCSS(html.CSS(default_css))
+	return doc
+}
+
+// AddPageCSS will add css to this page only, after the default CSS
+func (odie *Odie) AddPageCSS(css string) {
+	odie.Doc.AddCSS(html.CSS(css))
+}
+
+// AddPageScript will add a script tag loading src to this page's head
+func (odie *Odie) AddPageScript(src string) {
+	script := html.Script("")
+	script.AddAttr("src", src)
+	odie.Doc.Head().Add(script)
+}
+
+// AddPageScriptInline will add javascript to this page's head
+func (odie *Odie) AddPageScriptInline(js string) {
+	odie.Doc.Head().Add(html.Script(js))
+}
+
+// ParseForm will parse the request form, including the body, and make the values available in Url
+// Only needed when the App has AutoParseForm turned off
+func (odie *Odie) ParseForm() error {
+	err := odie.Request.ParseForm()
+	odie.Url.Query = odie.Request.Form
+	if odie.app != nil {
+		odie.Url.Query = odie.app.withDefaultQuery(odie.Request.Form)
+	}
+	return bodyError(err)
+}
+
+// bodyError will wrap an error from reading a body over Server.MaxBodyBytes with RequestTooLarge
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Errorf("%s: %w", err.Error(), RequestTooLarge)
+	}
+	return err
+}
+
+// Set will store a value for the rest of the request, such as a user resolved by middleware
+func (odie *Odie) Set(key string, value interface{}) {
+	if odie.values == nil {
+		odie.values = make(map[string]interface{})
+	}
+	odie.values[key] = value
+}
+
+// Get will return a value stored by Set, or nil
+func (odie *Odie) Get(key string) interface{} {
+	return odie.values[key]
+}
+
+// Param returns the value of a named path segment, such as id for a page registered as post/:id
+func (odie *Odie) Param(name string) string {
+	return odie.params[name]
+}
+
+// ParamInt returns the value of a named path segment as an int, such as the record id of post/:id.
+// A value that is not an int is a NotFound error, so Init can return it for a 404
+func (odie *Odie) ParamInt(name string) (int64, error) {
+	i, err := strconv.ParseInt(odie.params[name], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Param %s %q is not an int: %w", name, odie.params[name], NotFound)
+	}
+	return i, nil
 }
 
 func (odie *Odie) SetContentType(mimeType html.MimeType) {
@@ -278,9 +815,73 @@ func (odie *Odie) DefaultURL() *html.URL {
 	u.Anchor = ""
 	return u
 }
+
+// URLWithQuery will return the current url with its query string, changed by overrides.
+// Each key in overrides replaces the current values, a key with no values is removed.
+// The keys and values in the url's Query are escaped, as Link writes them as they are
+func (odie *Odie) URLWithQuery(overrides url.Values) *html.URL {
+	query := odie.Request.URL.Query()
+	for k, vs := range overrides {
+		if len(vs) == 0 {
+			delete(query, k)
+		} else {
+			query[k] = vs
+		}
+	}
+	u := pathURL(odie.Request.URL.EscapedPath(), odie.Request.URL.Path)
+	u.Query = escapeQuery(query)
+	return u
+}
+
+// escapeQuery returns q with its keys and values query escaped, html.URL.Link writes them without escaping
+func escapeQuery(q url.Values) url.Values {
+	escaped := make(url.Values, len(q))
+	for k, vs := range q {
+		ek := url.QueryEscape(k)
+		for _, v := range vs {
+			escaped[ek] = append(escaped[ek], url.QueryEscape(v))
+		}
+	}
+	return escaped
+}
+
+// CurrentURLWith returns the current url with params set in its query string, odie.Url is not changed
+func (odie *Odie) CurrentURLWith(params map[string]string) *html.URL {
+	overrides := make(url.Values, len(params))
+	for k, v := range params {
+		overrides.Set(k, v)
+	}
+	return odie.URLWithQuery(overrides)
+}
+
+// CurrentURLWithout returns the current url without keys in its query string, odie.Url is not changed
+func (odie *Odie) CurrentURLWithout(keys ...string) *html.URL {
+	overrides := make(url.Values, len(keys))
+	for _, k := range keys {
+		overrides[k] = nil
+	}
+	return odie.URLWithQuery(overrides)
+}
+
+// URLFor returns a url for page with params as its query string.  A page without a leading / is under the App's mount.
+// page is not escaped, each of its segments is path escaped, and params are query escaped in the url's Query
+func (odie *Odie) URLFor(page string, params map[string]string) *html.URL {
+	path := page
+	if !strings.HasPrefix(page, "/") {
+		path = odie.app.mount + "/" + page
+	}
+	u := pathURL(escapePath(path), page)
+	query := make(url.Values, len(params))
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	u.Query = escapeQuery(query)
+	return u
+}
+
 func (odie *Odie) HomeURL() *html.URL {
 	u := html.NewURL(odie.Request.URL, nil)
-	u.Name = "Home"
+	u.Name = odie.app.breadcrumbRoot
 	u.App = ""
 	u.Page = "/"
 	u.Query = nil
@@ -289,7 +890,17 @@ func (odie *Odie) HomeURL() *html.URL {
 	return u
 }
 
+// NewForm will create a form which submits action to this page, with a CSRF token if the Server secret is set
 func (odie *Odie) NewForm(action string) *html.FormElement {
+	f := odie.NewPublicForm(action)
+	if token := odie.CSRFToken(); len(token) > 0 {
+		f.Add(html.Hidden(csrfField, token))
+	}
+	return f
+}
+
+// NewPublicForm will create a form without a CSRF token, for forms that are intentionally public
+func (odie *Odie) NewPublicForm(action string) *html.FormElement {
 	l := html.NewLink(odie.Request.URL.Path)
 	f := html.Form(l)
 	/*
@@ -313,7 +924,7 @@ func (odie *Odie) ShowHeader(which string, urls []*html.URL) (*html.DivElement,
 	outerDiv := html.Div()
 	outerDiv.AddClassName("goodie" + which)
 
-	innerDiv := urlStack(urls)
+	innerDiv := urlStack(urls, odie.app.breadcrumbSeparator)
 
 	h := html.Heading(3, innerDiv)
 	outerDiv.Add(h)
@@ -321,7 +932,7 @@ func (odie *Odie) ShowHeader(which string, urls []*html.URL) (*html.DivElement,
 	return outerDiv, innerDiv
 }
 
-func urlStack(urls []*html.URL) *html.DivElement {
+func urlStack(urls []*html.URL, sep string) *html.DivElement {
 	div := html.Div()
 	for i, url := range urls {
 		// last one
@@ -329,7 +940,7 @@ func urlStack(urls []*html.URL) *html.DivElement {
 			div.Add(html.Text(url.Name))
 		} else {
 			div.Add(url)
-			div.Add(html.Text(">"))
+			div.Add(html.Text(sep))
 		}
 	}
 	return div
@@ -343,12 +954,18 @@ func (odie *Odie) Init() []*html.URL {
 }
 
 // RednerError is called anytime a fatal error is encountered
+// If the server has an error renderer, it is used instead of the default error page
 func (odie *Odie) RenderError(err error) {
+	if render := odie.app.odie.errorRenderer; render != nil {
+		render(odie, err)
+		return
+	}
+
 	odie.Body = odie.Doc.Body()
 	odie.Body.AddClassName("goodieerror")
 	odie.Body.Add(html.Text(err.Error()))
 
-	odie.Doc.Render(odie.Response)
+	writeDocument(odie.Response, odie.Doc, 0)
 }
 
 // Action will perform an action before the page loads.
@@ -372,171 +989,3 @@ func (odie *Odie) Display() {
 func (odie *Odie) Footer(urls []*html.URL) {
 	odie.ShowHeader("footer", urls)
 }
-
-func (odie *Odie) LoadFromQuery(iface interface{}) error {
-	rValue := reflect.ValueOf(iface)
-
-	fmt.Printf("LoadFromQuery %+v\n", iface)
-
-	switch rValue.Kind() {
-	case reflect.Ptr:
-		if rValue.IsNil() {
-			return fmt.Errorf("FromUrl: ptr is nil")
-		}
-		rValue = rValue.Elem()
-	default:
-		return fmt.Errorf("FromUrl: %T is not ptr", iface)
-	}
-
-	switch rValue.Kind() {
-	case reflect.Struct:
-		for i := 0; i != rValue.NumField(); i++ {
-			fieldValue := rValue.Field(i)
-
-			if !fieldValue.CanInterface() {
-				continue
-			}
-
-			field := rValue.Type().Field(i)
-			key := strings.ToLower(field.Name)
-
-			var q string
-			for _, key := range []string{strings.ToLower(field.Name), underscoreKey(field.Name)} {
-				q = odie.Url.GetQuery(key)
-				fmt.Printf("%s = '%s'\n", key, q)
-				if len(q) > 0 {
-					break
-				}
-			}
-			if len(q) == 0 {
-				continue
-			}
-
-			t := field.Type.Kind()
-			switch t {
-			case reflect.String:
-				fieldValue.SetString(q)
-			case reflect.Int64, reflect.Int:
-				n, err := strconv.ParseInt(q, 10, 64)
-				if err != nil {
-					return fmt.Errorf("Not an int: %s = %s (%s)", key, q, err.Error())
-				}
-				fieldValue.SetInt(n)
-			case reflect.Struct:
-				iface := rValue.Field(i).Interface()
-				switch iface.(type) {
-				case sql.NullInt64:
-					n, err := strconv.ParseInt(q, 10, 64)
-					if err != nil {
-						return fmt.Errorf("Not an int: %s = %s (%s)", key, q, err.Error())
-					}
-					si64 := sql.NullInt64{
-						Valid: true,
-						Int64: n,
-					}
-					v := reflect.ValueOf(si64)
-					fieldValue.Set(v)
-				default:
-					fmt.Println("Unsuported struct type %T %T for key: %s", field, iface, key)
-					return fmt.Errorf("Unsuported type %T %T for key: %s", field, iface, key)
-				}
-			default:
-				fmt.Println("Unsuported type %t for key: %s", field, key)
-				return fmt.Errorf("Unsuported type %T for key: %s", field, key)
-			}
-
-		}
-	case reflect.Slice, reflect.Array:
-		return fmt.Errorf("FromUrl: Can not decode %T", iface)
-	case reflect.Map:
-		return fmt.Errorf("FromUrl: Can not decode %T", iface)
-	default:
-		return fmt.Errorf("FromUrl: Can not decode %T", iface)
-	}
-	return nil
-}
-
-func (odie *Odie) DbInsert(v interface{}) error {
-	if odie.Orm == nil {
-		return fmt.Errorf("DB not configured")
-	}
-
-	affected, err := odie.Orm.Insert(v)
-	return expect("inserted", affected, 1, err, v)
-}
-func (odie *Odie) DbGet(id int64, v interface{}) error {
-	if odie.Orm == nil {
-		return fmt.Errorf("DB not configured")
-	}
-
-	// has, err := odie.Orm.Where(Eq{"id": id}).Get(v)
-	has, err := odie.Orm.ID(id).Get(v)
-	return hasRecords(has, err, id)
-}
-func (odie *Odie) DbDelete(v interface{}) error {
-	if odie.Orm == nil {
-		return fmt.Errorf("DB not configured")
-	}
-
-	affected, err := odie.Orm.Delete(v)
-	return expect("deleted", affected, 1, err, v)
-}
-func (odie *Odie) DbUpdate(id int64, v interface{}) error {
-	if odie.Orm == nil {
-		return fmt.Errorf("DB not configured")
-	}
-
-	affected, err := odie.Orm.ID(id).Update(v)
-	return expect("updated", affected, 1, err, v)
-}
-
-func (odie *Odie) GetAll(v interface{}) error {
-	if odie.Orm == nil {
-		return fmt.Errorf("DB not configured")
-	}
-
-	return odie.Orm.Find(v)
-}
-
-func (odie *Odie) GetOrder(v interface{}, order string) error {
-	if odie.Orm == nil {
-		return fmt.Errorf("DB not configured")
-	}
-
-	return odie.Orm.OrderBy(order).Find(v)
-}
-
-func expect(what string, affected int64, expected int64, err error, i interface{}) error {
-	if err != nil {
-		return err
-	}
-	if affected != expected {
-		return fmt.Errorf("Expected %d rows %s, Got %d for record %+v", expected, what, affected, i)
-	}
-	return nil
-}
-func hasRecords(has bool, err error, id int64) error {
-	if err != nil {
-		return err
-	}
-	if !has {
-		return fmt.Errorf("No Records Found for id %d", id)
-	}
-	return nil
-}
-
-func underscoreKey(key string) string {
-	runes := make([]rune, 0, len(key)*2)
-	for i, r := range key {
-		isUpper := 'A' <= r && r <= 'Z'
-		if isUpper {
-			if i > 0 {
-				runes = append(runes, '_')
-			}
-			r -= ('A' - 'a')
-		}
-		runes = append(runes, r)
-	}
-
-	return string(runes)
-}
@@ -0,0 +1,36 @@
+// Package goodietest serves requests to a goodie Server in memory, for testing handlers without opening a socket
+package goodietest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/debspencer/goodie"
+)
+
+// Request will serve a request through the Server's ServeHTTP, and return the recorded response.
+// form is the query string of a GET or HEAD, otherwise it is posted as a url encoded form
+func Request(s *goodie.Server, method string, path string, form url.Values) *httptest.ResponseRecorder {
+	method = strings.ToUpper(method)
+
+	var req *http.Request
+	if method == http.MethodGet || method == http.MethodHead || form == nil {
+		if len(form) > 0 {
+			sep := "?"
+			if strings.Contains(path, "?") {
+				sep = "&"
+			}
+			path += sep + form.Encode()
+		}
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		req = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
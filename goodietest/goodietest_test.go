@@ -0,0 +1,45 @@
+package goodietest
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/debspencer/goodie"
+	"github.com/debspencer/html"
+)
+
+// echoPage answers with the method and the q value of the request
+type echoPage struct {
+	goodie.Odie
+}
+
+func (p *echoPage) Init() ([]*html.URL, []byte, error) {
+	return nil, []byte(p.Request.Method + " " + p.Request.FormValue("q")), nil
+}
+
+func (p *echoPage) Display() {}
+
+func TestRequest(t *testing.T) {
+	s := goodie.Init(":0", nil)
+	s.SetLogger(goodie.NopLogger)
+	s.NewApp("").Register("echo", func() goodie.Handler { return &echoPage{} })
+
+	tests := []struct {
+		method string
+		path   string
+		form   url.Values
+		want   string
+	}{
+		{"get", "/echo", url.Values{"q": {"a b"}}, "GET a b"},
+		{"GET", "/echo?x=1", url.Values{"q": {"c"}}, "GET c"},
+		{"POST", "/echo", url.Values{"q": {"d&e"}}, "POST d&e"},
+		{"POST", "/echo?q=f", nil, "POST f"},
+	}
+	for _, tt := range tests {
+		rec := Request(s, tt.method, tt.path, tt.form)
+		if rec.Code != http.StatusOK || rec.Body.String() != tt.want {
+			t.Errorf("Request(%s %s %v) = %d %q, want 200 %q", tt.method, tt.path, tt.form, rec.Code, rec.Body.String(), tt.want)
+		}
+	}
+}
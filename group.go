@@ -0,0 +1,131 @@
+package goodie
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group registers pages of an App under a common prefix, with middleware shared by all of them
+type Group struct {
+	app        *App
+	parent     *Group
+	prefix     string
+	middleware []OdieMiddleware
+}
+
+type groupKey struct{}
+
+// Group returns a group of the App's pages under prefix, such as admin for /app/admin/...
+func (a *App) Group(prefix string) *Group {
+	return &Group{
+		app:    a,
+		prefix: groupPrefix(prefix),
+	}
+}
+
+// Group returns a group nested under g, with the prefixes and middleware of both
+func (g *Group) Group(prefix string) *Group {
+	return &Group{
+		app:    g.app,
+		parent: g,
+		prefix: g.prefix + groupPrefix(prefix),
+	}
+}
+
+func groupPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if len(prefix) == 0 {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// Use will add odie middleware around the lifecycle of every page in the group, inside the App's middleware.
+// It applies to pages already registered
+func (g *Group) Use(mw ...OdieMiddleware) {
+	g.middleware = append(g.middleware, mw...)
+}
+
+// BasicAuth will require HTTP basic authentication for the pages of the group
+func (g *Group) BasicAuth(realm string, check func(user string, pass string) bool) {
+	g.Use(basicAuth(realm, check))
+}
+
+// chain returns the middleware of the group's parents, then of the group
+func (g *Group) chain() []OdieMiddleware {
+	if g.parent == nil {
+		return g.middleware
+	}
+	return append(append([]OdieMiddleware{}, g.parent.chain()...), g.middleware...)
+}
+
+// Register will register a handler for page under the group, matching all HTTP methods
+func (g *Group) Register(page string, h NewHandler) {
+	g.RegisterMethod("", page, h)
+}
+
+// RegisterFunc will register a handler for page under the group, created by fn, matching all HTTP methods
+func (g *Group) RegisterFunc(page string, fn HandlerFunc) {
+	g.register("", page, AppHandler{
+		factory: fn,
+		app:     g.app,
+	})
+}
+
+// RegisterMethod will register a handler for page under the group that only responds to the given HTTP method
+func (g *Group) RegisterMethod(method string, page string, h NewHandler) {
+	g.register(method, page, AppHandler{
+		handler: h,
+		app:     g.app,
+	})
+}
+
+// RegisterMethods will register a handler for page under the group that responds to each of methods
+func (g *Group) RegisterMethods(page string, h NewHandler, methods ...string) {
+	for _, method := range methods {
+		g.RegisterMethod(method, page, h)
+	}
+}
+
+// RegisterGet will register a handler for GET, and so HEAD, requests to page under the group
+func (g *Group) RegisterGet(page string, h NewHandler) {
+	g.RegisterMethod(http.MethodGet, page, h)
+}
+
+// RegisterPost will register a handler for POST requests to page under the group
+func (g *Group) RegisterPost(page string, h NewHandler) {
+	g.RegisterMethod(http.MethodPost, page, h)
+}
+
+// RegisterPut will register a handler for PUT requests to page under the group
+func (g *Group) RegisterPut(page string, h NewHandler) {
+	g.RegisterMethod(http.MethodPut, page, h)
+}
+
+// RegisterPatch will register a handler for PATCH requests to page under the group
+func (g *Group) RegisterPatch(page string, h NewHandler) {
+	g.RegisterMethod(http.MethodPatch, page, h)
+}
+
+// RegisterDelete will register a handler for DELETE requests to page under the group
+func (g *Group) RegisterDelete(page string, h NewHandler) {
+	g.RegisterMethod(http.MethodDelete, page, h)
+}
+
+func (g *Group) register(method string, page string, ah AppHandler) {
+	page = strings.TrimPrefix(page, "/")
+	if len(page) > 0 {
+		page = "/" + page
+	}
+	ah.group = g
+	g.app.register(method, g.prefix+page, ah)
+}
+
+// groupMiddleware returns the middleware of the group that registered the page of req, after the App's middleware
+func groupMiddleware(app *App, req *http.Request) []OdieMiddleware {
+	g, ok := req.Context().Value(groupKey{}).(*Group)
+	if !ok {
+		return app.middleware
+	}
+	return append(append([]OdieMiddleware{}, app.middleware...), g.chain()...)
+}
@@ -0,0 +1,50 @@
+package goodie
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+func TestHead(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.RegisterGet("data", textPage("some data"))
+	app.Register("page", func() Handler {
+		return &testPage{display: func(p *testPage) {
+			p.Body.Add(html.Text("a page"))
+		}}
+	})
+	app.Register("missing", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, NotFound
+	}))
+	app.RegisterPost("post", textPage("posted"))
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/app/data", http.StatusOK},
+		{"/app/page", http.StatusOK},
+		{"/app/missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		get := serveTest(s, "GET", tt.target, "")
+		head := serveTest(s, "HEAD", tt.target, "")
+		if head.Code != tt.status || get.Code != tt.status {
+			t.Errorf("%s status HEAD = %d, GET = %d, want %d", tt.target, head.Code, get.Code, tt.status)
+		}
+		if head.Body.Len() > 0 {
+			t.Errorf("HEAD %s has a body %q", tt.target, head.Body.String())
+		}
+		if got, want := head.Header().Get("Content-Length"), get.Header().Get("Content-Length"); got != want || len(got) == 0 {
+			t.Errorf("HEAD %s Content-Length = %q, GET = %q", tt.target, got, want)
+		}
+	}
+
+	rec := serveTest(s, "HEAD", "/app/post", "")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("HEAD of a POST page status = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,35 @@
+package goodie
+
+import (
+	"net/http"
+)
+
+var defaultHealthPath = "/healthz"
+
+// AddHealthCheck will answer requests for path, defaulting to /healthz, with 200 ok when the database of every App
+// can be pinged, or 503 with the name of the failing App.  It is answered before the middleware and the handlers
+func (s *Server) AddHealthCheck(path string) {
+	if len(path) == 0 {
+		path = defaultHealthPath
+	}
+	s.healthPath = path
+}
+
+// health will ping the database of each App
+func (s *Server) health(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", defaultCacheControl)
+
+	for _, app := range s.apps {
+		if app.orm == nil {
+			continue
+		}
+		if err := app.orm.PingContext(req.Context()); err != nil {
+			s.log().Errorf("health check %s: %s", app.name, err.Error())
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(app.name + "\n"))
+			return
+		}
+	}
+	w.Write([]byte("ok\n"))
+}
@@ -0,0 +1,70 @@
+package goodie
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/debspencer/html"
+)
+
+// testPage is a Handler whose Init and Display are set by the test
+type testPage struct {
+	Odie
+	init    func(p *testPage) ([]*html.URL, []byte, error)
+	display func(p *testPage)
+}
+
+func (p *testPage) Init() ([]*html.URL, []byte, error) {
+	if p.init != nil {
+		return p.init(p)
+	}
+	return nil, nil, nil
+}
+
+func (p *testPage) Display() {
+	if p.display != nil {
+		p.display(p)
+	}
+}
+
+// newPage returns a NewHandler for a testPage with init
+func newPage(init func(p *testPage) ([]*html.URL, []byte, error)) NewHandler {
+	return func() Handler {
+		return &testPage{init: init}
+	}
+}
+
+// textPage returns a NewHandler for a page answering with text as its data
+func textPage(text string) NewHandler {
+	return newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, []byte(text), nil
+	})
+}
+
+func newTestServer() *Server {
+	s := Init(":0", nil)
+	s.SetLogger(NopLogger)
+	return s
+}
+
+// serveTest will serve a request with body, a url encoded form if it is not empty
+func serveTest(s *Server, method string, target string, body string) *httptest.ResponseRecorder {
+	var r io.Reader
+	if len(body) > 0 {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return serveRequest(s, req)
+}
+
+// serveRequest will serve req, for a test that needs to set its headers or cookies
+func serveRequest(s *Server, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
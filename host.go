@@ -0,0 +1,101 @@
+package goodie
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// routes are the pages registered for the server, or for one Host
+type routes struct {
+	handlers map[string]*route
+	params   paramNode
+	matchers []matchHandler
+}
+
+// Host is a routing scope for requests to one hostname, such as admin.example.com, or to the subdomains
+// of a domain, such as *.example.com.  Apps created on it only serve requests to the host, and requests to
+// the host are only served by them.  Static files, the favicon, health and metrics are shared by every host
+type Host struct {
+	routes
+	s    *Server
+	name string
+}
+
+// Host returns the routing scope for requests to name, an exact hostname or *.domain for its subdomains.
+// The port is ignored, calling Host again with the same name returns the same scope
+func (s *Server) Host(name string) *Host {
+	name = normalizeHost(name)
+	if h, ok := s.hosts[name]; ok {
+		return h
+	}
+
+	h := &Host{
+		routes: routes{
+			handlers: make(map[string]*route),
+		},
+		s:    s,
+		name: name,
+	}
+	if s.hosts == nil {
+		s.hosts = make(map[string]*Host)
+	}
+	s.hosts[name] = h
+	if strings.HasPrefix(name, "*.") {
+		s.wildcardHosts = append(s.wildcardHosts, h)
+	}
+	return h
+}
+
+// Name returns the hostname of the scope
+func (h *Host) Name() string {
+	return h.name
+}
+
+// NewApp will create an App whose pages only serve requests to the host
+func (h *Host) NewApp(name string) *App {
+	app := h.s.NewApp(name)
+	app.host = h
+	return app
+}
+
+// NewAppAt will create an App whose pages are under prefix, and only serve requests to the host
+func (h *Host) NewAppAt(name string, prefix string) *App {
+	app := h.NewApp(name)
+	app.SetMount(prefix)
+	return app
+}
+
+// routes returns where the App's pages are registered
+func (a *App) routes() *routes {
+	if a.host != nil {
+		return &a.host.routes
+	}
+	return &a.odie.routes
+}
+
+// hostRoutes returns the pages for the host of req, those of a Host scope or the server's
+func (s *Server) hostRoutes(req *http.Request) *routes {
+	if len(s.hosts) == 0 {
+		return &s.routes
+	}
+
+	name := normalizeHost(req.Host)
+	if h, ok := s.hosts[name]; ok {
+		return &h.routes
+	}
+	for _, h := range s.wildcardHosts {
+		if strings.HasSuffix(name, h.name[1:]) {
+			return &h.routes
+		}
+	}
+	return &s.routes
+}
+
+// normalizeHost will lower case host, and remove any port and trailing dot
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,55 @@
+package goodie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostRouting(t *testing.T) {
+	s := newTestServer()
+	s.NewApp("app").Register("page", textPage("default"))
+	s.Host("admin.example.com").NewAppAt("admin", "/app").Register("page", textPage("admin"))
+	s.Host("*.users.example.com").NewAppAt("users", "/app").Register("page", textPage("users"))
+	s.Host("Only.Example.com:8080").NewApp("only").Register("page", textPage("only"))
+
+	tests := []struct {
+		name   string
+		host   string
+		target string
+		status int
+		body   string
+	}{
+		{"exact host", "admin.example.com", "/app/page", http.StatusOK, "admin"},
+		{"exact host with a port", "admin.example.com:8080", "/app/page", http.StatusOK, "admin"},
+		{"exact host upper case with a trailing dot", "ADMIN.example.com.", "/app/page", http.StatusOK, "admin"},
+		{"subdomain", "ann.users.example.com", "/app/page", http.StatusOK, "users"},
+		{"nested subdomain with a port", "a.b.users.example.com:443", "/app/page", http.StatusOK, "users"},
+		{"wildcard domain itself", "users.example.com", "/app/page", http.StatusOK, "default"},
+		{"name registered with a port", "only.example.com", "/only/page", http.StatusOK, "only"},
+		{"fallback to the default scope", "www.example.com", "/app/page", http.StatusOK, "default"},
+		{"no host", "", "/app/page", http.StatusOK, "default"},
+
+		// a page of a host is not served for other hosts, nor the default pages for the host
+		{"host page from an unknown host", "www.example.com", "/only/page", http.StatusNotFound, ""},
+		{"unknown page of a host", "admin.example.com", "/only/page", http.StatusNotFound, ""},
+		{"default page of a host", "only.example.com", "/app/page", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.Host = tt.host
+			rec := serveRequest(s, req)
+			if rec.Code != tt.status {
+				t.Fatalf("GET %s%s status = %d, want %d", tt.host, tt.target, rec.Code, tt.status)
+			}
+			if tt.status == http.StatusOK && rec.Body.String() != tt.body {
+				t.Errorf("GET %s%s = %q, want %q", tt.host, tt.target, rec.Body.String(), tt.body)
+			}
+		})
+	}
+
+	if got := s.Host("ADMIN.example.com:80"); got != s.Host("admin.example.com") {
+		t.Errorf("Host with a port and case = %v, want the same scope", got.Name())
+	}
+}
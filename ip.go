@@ -0,0 +1,98 @@
+package goodie
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// localhost is allowed by RegisterInternal when no networks are given
+var localhost = []string{"127.0.0.0/8", "::1/128"}
+
+// SetTrustedProxies sets the proxies, as IPs or CIDRs, whose X-Forwarded-For header is used by ClientIP
+func (s *Server) SetTrustedProxies(proxies ...string) {
+	s.trustedProxies = s.parseNets(proxies)
+}
+
+// parseNets will parse IPs and CIDRs, logging and skipping any that are invalid
+func (s *Server) parseNets(addrs []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "/") {
+			if ip := net.ParseIP(addr); ip != nil && ip.To4() != nil {
+				addr += "/32"
+			} else {
+				addr += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(addr)
+		if err != nil {
+			s.log().Errorf("Invalid network %s: %s", addr, err.Error())
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP of the client, from X-Forwarded-For when the request is from a trusted proxy
+func (odie *Odie) ClientIP() net.IP {
+	return odie.server().clientIP(odie.Request)
+}
+
+func (s *Server) clientIP(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || s == nil || !containsIP(s.trustedProxies, ip) {
+		return ip
+	}
+
+	// walk back through the proxies, the first untrusted address is the client
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !containsIP(s.trustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// RegisterInternal will register a handler for page which only responds to clients in the allowed IPs or CIDRs,
+// other clients get a 403 Forbidden.  With no allowed networks only localhost is allowed
+func (a *App) RegisterInternal(page string, h NewHandler, allowed ...string) {
+	if len(allowed) == 0 {
+		allowed = localhost
+	}
+	a.register("", page, AppHandler{
+		handler:  h,
+		app:      a,
+		internal: true,
+		allowed:  a.odie.parseNets(allowed),
+	})
+}
+
+// permitted checks the client is allowed by an internal handler
+func (ah AppHandler) permitted(s *Server, req *http.Request) bool {
+	if !ah.internal {
+		return true
+	}
+	ip := s.clientIP(req)
+	return ip != nil && containsIP(ah.allowed, ip)
+}
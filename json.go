@@ -0,0 +1,99 @@
+package goodie
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// jsonPage is the envelope written by WriteJSONPage
+type jsonPage struct {
+	Data    interface{} `json:"data"`
+	Page    int         `json:"page"`
+	PerPage int         `json:"per_page"`
+	Total   int64       `json:"total"`
+	Pages   int64       `json:"pages"`
+}
+
+// WriteJSONPage will write one page of items, with the paging metadata, as JSON.
+// No document will be rendered
+func (odie *Odie) WriteJSONPage(items interface{}, page int, perPage int, total int64) error {
+	if items == nil {
+		items = []interface{}{}
+	} else if rv := reflect.ValueOf(items); rv.Kind() == reflect.Slice && rv.IsNil() {
+		items = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+
+	var pages int64
+	if perPage > 0 {
+		pages = (total + int64(perPage) - 1) / int64(perPage)
+	}
+
+	return odie.writeJSON(http.StatusOK, jsonPage{
+		Data:    items,
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+		Pages:   pages,
+	})
+}
+
+// RenderJSON will write v as a JSON response, no document will be rendered.
+// It can be called from Init or Action, return its error so a marshaling error is rendered with RenderError
+func (odie *Odie) RenderJSON(v interface{}) error {
+	return odie.writeJSON(http.StatusOK, v)
+}
+
+// problem is an RFC 7807 problem document, as written by WriteValidationErrors
+type problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	InvalidParams []invalidParam `json:"invalid-params"`
+}
+
+type invalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// WriteValidationErrors will write a 422 application/problem+json document listing each invalid field
+// in errs with its message.  No document will be rendered
+func (odie *Odie) WriteValidationErrors(errs map[string]string) error {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]invalidParam, 0, len(names))
+	for _, name := range names {
+		params = append(params, invalidParam{Name: name, Reason: errs[name]})
+	}
+
+	return odie.writeJSONType(http.StatusUnprocessableEntity, "application/problem+json", problem{
+		Type:          "about:blank",
+		Title:         "Your request parameters didn't validate",
+		Status:        http.StatusUnprocessableEntity,
+		InvalidParams: params,
+	})
+}
+
+// writeJSON will write v as the JSON response with status
+func (odie *Odie) writeJSON(status int, v interface{}) error {
+	return odie.writeJSONType(status, "application/json", v)
+}
+
+func (odie *Odie) writeJSONType(status int, contentType string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	odie.Response.Header().Set("Content-type", contentType)
+	odie.Response.WriteHeader(status)
+	odie.Response.Write(data)
+	odie.committed = true
+	return nil
+}
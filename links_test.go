@@ -0,0 +1,125 @@
+package goodie
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func linkOdie(target string) *Odie {
+	return &Odie{
+		Request: httptest.NewRequest("GET", target, nil),
+		app:     &App{mount: "/app"},
+	}
+}
+
+func parseLink(t *testing.T, link string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Parse %q: %s", link, err)
+	}
+	return u
+}
+
+func TestURLForEscapes(t *testing.T) {
+	reserved := "a&b=c #d%e+f/g?h"
+	odie := linkOdie("/app/list")
+
+	tests := []struct {
+		page     string
+		params   map[string]string
+		wantPath string
+	}{
+		{"item", map[string]string{"q": reserved}, "/app/item"},
+		{"my item", map[string]string{"q": reserved, "other": "1"}, "/app/my item"},
+		{"/root/a?b", map[string]string{reserved: "key"}, "/root/a?b"},
+	}
+	for _, tt := range tests {
+		link := odie.URLFor(tt.page, tt.params).Link()
+		u := parseLink(t, link)
+		if u.Path != tt.wantPath {
+			t.Errorf("URLFor(%q) path = %q, want %q (link %s)", tt.page, u.Path, tt.wantPath, link)
+		}
+		if len(u.Fragment) > 0 {
+			t.Errorf("URLFor(%q) has fragment %q (link %s)", tt.page, u.Fragment, link)
+		}
+		q := u.Query()
+		if len(q) != len(tt.params) {
+			t.Errorf("URLFor(%q) query = %v, want %v (link %s)", tt.page, q, tt.params, link)
+		}
+		for k, v := range tt.params {
+			if got := q.Get(k); got != v {
+				t.Errorf("URLFor(%q) query %q = %q, want %q (link %s)", tt.page, k, got, v, link)
+			}
+		}
+	}
+}
+
+func TestCurrentURLWithEscapes(t *testing.T) {
+	odie := linkOdie("/app/list%20all?keep=x%26y&drop=1")
+
+	link := odie.CurrentURLWith(map[string]string{"q": "a&b=c#d"}).Link()
+	u := parseLink(t, link)
+	if u.Path != "/app/list all" {
+		t.Errorf("CurrentURLWith path = %q (link %s)", u.Path, link)
+	}
+	q := u.Query()
+	if q.Get("keep") != "x&y" || q.Get("drop") != "1" || q.Get("q") != "a&b=c#d" || len(q) != 3 {
+		t.Errorf("CurrentURLWith query = %v (link %s)", q, link)
+	}
+
+	link = odie.CurrentURLWithout("drop").Link()
+	q = parseLink(t, link).Query()
+	if q.Get("keep") != "x&y" || len(q) != 1 {
+		t.Errorf("CurrentURLWithout query = %v (link %s)", q, link)
+	}
+}
+
+func TestRouteURL(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	h := textPage("")
+	app.RegisterNamed("edit", "item/:id|int/edit", h)
+	app.RegisterNamed("tag", "tag/:name", h)
+	app.RegisterNamed("files", "files/*path", h)
+
+	tests := []struct {
+		name      string
+		pairs     []interface{}
+		wantPath  string
+		wantQuery url.Values
+		wantErr   bool
+	}{
+		{"edit", []interface{}{"id", 42}, "/app/item/42/edit", url.Values{}, false},
+		{"edit", []interface{}{"id", 42, "q", "a&b=c#d"}, "/app/item/42/edit", url.Values{"q": {"a&b=c#d"}}, false},
+		{"edit", []interface{}{"id", "x"}, "", nil, true},
+		{"edit", []interface{}{}, "", nil, true},
+		{"tag", []interface{}{"name", "a b?c"}, "/app/tag/a b?c", url.Values{}, false},
+		{"tag", []interface{}{"name", "a/b"}, "", nil, true},
+		{"files", []interface{}{"path", "a b/c"}, "/app/files/a b/c", url.Values{}, false},
+		{"missing", nil, "", nil, true},
+		{"edit", []interface{}{"id"}, "", nil, true},
+	}
+	for _, tt := range tests {
+		u, err := app.RouteURL(tt.name, tt.pairs...)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("RouteURL(%s, %v) = %s, want an error", tt.name, tt.pairs, u.Link())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("RouteURL(%s, %v): %s", tt.name, tt.pairs, err)
+			continue
+		}
+		link := u.Link()
+		got := parseLink(t, link)
+		if got.Path != tt.wantPath {
+			t.Errorf("RouteURL(%s, %v) path = %q, want %q (link %s)", tt.name, tt.pairs, got.Path, tt.wantPath, link)
+		}
+		if got.Query().Encode() != tt.wantQuery.Encode() {
+			t.Errorf("RouteURL(%s, %v) query = %v, want %v (link %s)", tt.name, tt.pairs, got.Query(), tt.wantQuery, link)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package goodie
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Logger is used for all of the server's logging
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// NopLogger discards all messages
+var NopLogger Logger = nopLogger{}
+
+// defaultLogger is used by a Server without a Logger, it writes info and errors to stderr
+var defaultLogger = NewLogger(os.Stderr, false)
+
+type serverKey struct{}
+
+// NewLogger returns a Logger that writes to w, debug messages are only written if debug is true
+func NewLogger(w io.Writer, debug bool) Logger {
+	return &writerLogger{
+		log:   log.New(w, "", log.LstdFlags),
+		debug: debug,
+	}
+}
+
+type writerLogger struct {
+	log   *log.Logger
+	debug bool
+}
+
+func (l *writerLogger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.log.Printf("DEBUG "+format, args...)
+	}
+}
+
+func (l *writerLogger) Infof(format string, args ...interface{}) {
+	l.log.Printf("INFO "+format, args...)
+}
+
+func (l *writerLogger) Errorf(format string, args ...interface{}) {
+	l.log.Printf("ERROR "+format, args...)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Infof(format string, args ...interface{})  {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+// SetLogger will set the Logger for the server and its apps, use NopLogger to turn logging off
+func (s *Server) SetLogger(l Logger) {
+	s.logger = l
+}
+
+func (s *Server) log() Logger {
+	if s == nil || s.logger == nil {
+		return defaultLogger
+	}
+	return s.logger
+}
+
+func (odie *Odie) log() Logger {
+	return odie.server().log()
+}
+
+// requestServer returns the server handling req, for middleware which is not a Server method
+func requestServer(req *http.Request) *Server {
+	s, _ := req.Context().Value(serverKey{}).(*Server)
+	return s
+}
+
+// requestLogger returns the Logger of the server handling req
+func requestLogger(req *http.Request) Logger {
+	return requestServer(req).log()
+}
+
+func withServer(req *http.Request, s *Server) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), serverKey{}, s))
+}
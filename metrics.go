@@ -0,0 +1,184 @@
+package goodie
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	defaultMetricsPath   = "/metrics"
+	defaultMetricsPrefix = "goodie"
+
+	// metricBuckets are the upper bounds in seconds of the request duration histogram
+	metricBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// metricMethods are the method label values, any other method is counted as OTHER
+	metricMethods = map[string]string{
+		http.MethodGet:     http.MethodGet,
+		http.MethodHead:    http.MethodHead,
+		http.MethodPost:    http.MethodPost,
+		http.MethodPut:     http.MethodPut,
+		http.MethodPatch:   http.MethodPatch,
+		http.MethodDelete:  http.MethodDelete,
+		http.MethodOptions: http.MethodOptions,
+	}
+
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// metrics holds the request metrics of a Server
+type metrics struct {
+	path     string
+	prefix   string
+	inFlight int64
+
+	mu     sync.RWMutex
+	series map[metricKey]*metricSeries
+}
+
+// metricKey labels a series, the route is the registered page pattern so the number of series is bounded
+type metricKey struct {
+	method string
+	route  string
+}
+
+type metricSeries struct {
+	mu       sync.Mutex
+	statuses map[int]uint64
+	buckets  []uint64 // count of durations <= each of metricBuckets
+	count    uint64
+	sum      float64
+}
+
+// EnableMetrics will answer requests for path, defaulting to /metrics, with the request counts, requests in flight,
+// and a histogram of request durations in the Prometheus text format, labeled by method and route.
+// It is answered before the middleware and the handlers
+func (s *Server) EnableMetrics(path string) {
+	if len(path) == 0 {
+		path = defaultMetricsPath
+	}
+	if s.metrics == nil {
+		s.metrics = &metrics{
+			prefix: defaultMetricsPrefix,
+			series: make(map[metricKey]*metricSeries),
+		}
+	}
+	s.metrics.path = path
+}
+
+// SetMetricsPrefix sets the prefix of the metric names, defaults to goodie, as in goodie_requests_total
+func (s *Server) SetMetricsPrefix(prefix string) {
+	if s.metrics == nil {
+		s.EnableMetrics("")
+	}
+	s.metrics.prefix = strings.TrimSuffix(prefix, "_")
+}
+
+// observe will record a served request
+func (m *metrics) observe(method string, route string, status int, d time.Duration) {
+	label, ok := metricMethods[method]
+	if !ok {
+		label = "OTHER"
+	}
+	key := metricKey{method: label, route: route}
+
+	m.mu.RLock()
+	series, ok := m.series[key]
+	m.mu.RUnlock()
+	if !ok {
+		m.mu.Lock()
+		series, ok = m.series[key]
+		if !ok {
+			series = &metricSeries{
+				statuses: make(map[int]uint64),
+				buckets:  make([]uint64, len(metricBuckets)),
+			}
+			m.series[key] = series
+		}
+		m.mu.Unlock()
+	}
+
+	seconds := d.Seconds()
+	series.mu.Lock()
+	series.statuses[status]++
+	for i, le := range metricBuckets {
+		if seconds <= le {
+			series.buckets[i]++
+		}
+	}
+	series.count++
+	series.sum += seconds
+	series.mu.Unlock()
+}
+
+// serve will write the metrics in the Prometheus text exposition format
+func (m *metrics) serve(w http.ResponseWriter, req *http.Request) {
+	m.mu.RLock()
+	keys := make([]metricKey, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	m.mu.RUnlock()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	var sb strings.Builder
+	total := m.prefix + "_requests_total"
+	fmt.Fprintf(&sb, "# HELP %s Total HTTP requests served.\n# TYPE %s counter\n", total, total)
+	for _, key := range keys {
+		series := m.lookup(key)
+		series.mu.Lock()
+		statuses := make([]int, 0, len(series.statuses))
+		for status := range series.statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(&sb, "%s{%s,status=\"%d\"} %d\n", total, key.labels(), status, series.statuses[status])
+		}
+		series.mu.Unlock()
+	}
+
+	inFlight := m.prefix + "_requests_in_flight"
+	fmt.Fprintf(&sb, "# HELP %s HTTP requests being served.\n# TYPE %s gauge\n", inFlight, inFlight)
+	fmt.Fprintf(&sb, "%s %d\n", inFlight, atomic.LoadInt64(&m.inFlight))
+
+	duration := m.prefix + "_request_duration_seconds"
+	fmt.Fprintf(&sb, "# HELP %s HTTP request duration in seconds.\n# TYPE %s histogram\n", duration, duration)
+	for _, key := range keys {
+		series := m.lookup(key)
+		labels := key.labels()
+		series.mu.Lock()
+		for i, le := range metricBuckets {
+			fmt.Fprintf(&sb, "%s_bucket{%s,le=\"%g\"} %d\n", duration, labels, le, series.buckets[i])
+		}
+		fmt.Fprintf(&sb, "%s_bucket{%s,le=\"+Inf\"} %d\n", duration, labels, series.count)
+		fmt.Fprintf(&sb, "%s_sum{%s} %g\n", duration, labels, series.sum)
+		fmt.Fprintf(&sb, "%s_count{%s} %d\n", duration, labels, series.count)
+		series.mu.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", defaultCacheControl)
+	w.Write([]byte(sb.String()))
+}
+
+func (m *metrics) lookup(key metricKey) *metricSeries {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.series[key]
+}
+
+// labels returns the method and route labels of the series
+func (k metricKey) labels() string {
+	return fmt.Sprintf("method=\"%s\",route=\"%s\"", k.method, labelEscaper.Replace(k.route))
+}
@@ -0,0 +1,58 @@
+package goodie
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// TimeoutMessage is the body of the 503 written by Timeout
+var TimeoutMessage = "The server took too long to respond, please try again"
+
+// Middleware wraps the handling of a request
+type Middleware func(http.Handler) http.Handler
+
+// Use will add middleware around the dispatch of every request.
+// Middleware is executed in the order registered, the first registered is the outermost
+func (s *Server) Use(mw ...Middleware) {
+	s.middleware = append(s.middleware, mw...)
+}
+
+// OdieHandler handles a request after the Odie has been constructed
+type OdieHandler func(odie *Odie)
+
+// OdieMiddleware wraps the page lifecycle of an App.  It has access to the Odie before Init is called,
+// and may short circuit by writing a response and not calling next
+type OdieMiddleware func(next OdieHandler) OdieHandler
+
+// Use will add odie middleware around the lifecycle of every page in the App, in the order registered
+func (a *App) Use(mw ...OdieMiddleware) {
+	a.middleware = append(a.middleware, mw...)
+}
+
+// Recover is middleware that turns a panic in a handler into a 500 Internal Server Error
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			requestLogger(req).Errorf("panic: %s %v\n%s", req.URL.Path, r, debug.Stack())
+			http.Error(w, ServerError.Error(), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// Timeout is middleware that cancels the request context after d, so Db queries stop, and writes a 503 Service Unavailable
+// if the handler has not finished.  The response is buffered until the handler finishes, so it does not suit
+// streaming responses such as TailFile.  A panic in the handler still reaches Recover registered before Timeout
+func Timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, TimeoutMessage)
+	}
+}
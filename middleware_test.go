@@ -0,0 +1,179 @@
+package goodie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/debspencer/html"
+)
+
+// traceMiddleware records its name in trace before and after next
+func traceMiddleware(name string, trace *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			*trace = append(*trace, name)
+			next.ServeHTTP(w, req)
+			*trace = append(*trace, "/"+name)
+		})
+	}
+}
+
+// traceOdieMiddleware records its name in trace before and after next
+func traceOdieMiddleware(name string, trace *[]string) OdieMiddleware {
+	return func(next OdieHandler) OdieHandler {
+		return func(odie *Odie) {
+			*trace = append(*trace, name)
+			next(odie)
+			*trace = append(*trace, "/"+name)
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var trace []string
+	s := newTestServer()
+	s.Use(traceMiddleware("s1", &trace), traceMiddleware("s2", &trace))
+	app := s.NewApp("app")
+	app.Use(traceOdieMiddleware("a1", &trace))
+	app.Use(traceOdieMiddleware("a2", &trace))
+	admin := app.Group("admin")
+	admin.Use(traceOdieMiddleware("g1", &trace))
+	page := newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		trace = append(trace, "page")
+		return nil, nil, nil
+	})
+	app.Register("page", page)
+	admin.Register("page", page)
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/app/page", "s1 s2 a1 a2 page /a2 /a1 /s2 /s1"},
+		{"/app/admin/page", "s1 s2 a1 a2 g1 page /g1 /a2 /a1 /s2 /s1"},
+		{"/app/missing", "s1 s2 /s2 /s1"},
+	}
+	for _, tt := range tests {
+		trace = nil
+		serveTest(s, "GET", tt.target, "")
+		if got := strings.Join(trace, " "); got != tt.want {
+			t.Errorf("GET %s trace = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	s := newTestServer()
+	s.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Query().Get("block") == "server" {
+				w.WriteHeader(http.StatusTeapot)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+	app := s.NewApp("app")
+	app.Use(func(next OdieHandler) OdieHandler {
+		return func(odie *Odie) {
+			if odie.Request.URL.Query().Get("block") == "app" {
+				odie.Response.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next(odie)
+		}
+	})
+	app.Register("page", textPage("page"))
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/app/page", http.StatusOK, "page"},
+		{"/app/page?block=server", http.StatusTeapot, ""},
+		{"/app/page?block=app", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, "GET", tt.target, "")
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.target, rec.Code, rec.Body.String(), tt.status, tt.body)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/panic" {
+			panic("handler")
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/ok", http.StatusOK},
+		{"/panic", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", tt.target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	s := newTestServer()
+	s.Use(Recover, Timeout(50*time.Millisecond))
+	app := s.NewApp("app")
+	app.Register("fast", textPage("fast"))
+
+	cancelled := make(chan bool, 1)
+	app.Register("slow", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		select {
+		case <-p.Request.Context().Done():
+			cancelled <- true
+		case <-time.After(5 * time.Second):
+			cancelled <- false
+		}
+		return nil, []byte("slow"), nil
+	}))
+	app.Register("panic", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		panic("under Timeout")
+	}))
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/app/fast", http.StatusOK, "fast"},
+		{"/app/slow", http.StatusServiceUnavailable, TimeoutMessage},
+		{"/app/panic", http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, "GET", tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+		if len(tt.body) > 0 && rec.Body.String() != tt.body {
+			t.Errorf("GET %s = %q, want %q", tt.target, rec.Body.String(), tt.body)
+		}
+	}
+
+	select {
+	case ok := <-cancelled:
+		if !ok {
+			t.Errorf("the request context of the slow page was not cancelled")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the slow page did not finish")
+	}
+}
@@ -0,0 +1,45 @@
+package goodie
+
+import (
+	"strings"
+
+	"github.com/debspencer/html"
+)
+
+var defaultMimeType = "application/octet-stream"
+
+// RegisterMimeType will map a file extension, such as .csv, to a mime type for SetContentTypeExt.
+// Common extensions are already known, this adds to or overrides them
+func (s *Server) RegisterMimeType(ext string, mime string) {
+	if s.mimeTypes == nil {
+		s.mimeTypes = make(map[string]string)
+	}
+	s.mimeTypes[normalizeExt(ext)] = mime
+}
+
+// MimeType returns the mime type for a file extension, unknown extensions are application/octet-stream
+func (s *Server) MimeType(ext string) string {
+	ext = normalizeExt(ext)
+	if s != nil {
+		if mime, ok := s.mimeTypes[ext]; ok {
+			return mime
+		}
+	}
+	if mt, ok := html.Mimes[ext]; ok {
+		return mt.Mime
+	}
+	return defaultMimeType
+}
+
+// SetContentTypeExt will set the content type for a file extension, such as .csv or .pdf
+func (odie *Odie) SetContentTypeExt(ext string) {
+	odie.Response.Header().Set("Content-type", odie.server().MimeType(ext))
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
@@ -0,0 +1,115 @@
+package goodie
+
+import (
+	"strconv"
+	"strings"
+)
+
+var defaultType = "text/html"
+
+// acceptRange is one media range of an Accept header, such as text/* or application/json;q=0.8
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// parseAccept will parse an Accept header, ranges without a valid q have a q of 1
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mt := strings.ToLower(strings.TrimSpace(params[0]))
+		slash := strings.Index(mt, "/")
+		if slash <= 0 {
+			continue
+		}
+
+		r := acceptRange{typ: mt[:slash], subtype: mt[slash+1:], q: 1}
+		for _, p := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// quality returns the q of the most specific range matching mime, and its specificity, -1 if none match
+func quality(ranges []acceptRange, mime string) (float64, int) {
+	mime = strings.ToLower(mime)
+	slash := strings.Index(mime, "/")
+	if slash <= 0 {
+		return 0, -1
+	}
+	typ, subtype := mime[:slash], mime[slash+1:]
+
+	q, specific := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		}
+		if s > specific {
+			q, specific = r.q, s
+		}
+	}
+	return q, specific
+}
+
+// Accepts will check if the Accept header of the request allows mime, such as application/json.
+// A request without an Accept header accepts anything
+func (odie *Odie) Accepts(mime string) bool {
+	header := odie.Request.Header.Get("Accept")
+	if len(strings.TrimSpace(header)) == 0 {
+		return true
+	}
+	q, _ := quality(parseAccept(header), mime)
+	return q > 0
+}
+
+// PreferredType will return the offered type the request's Accept header weights highest, or an empty string if none
+// are acceptable.  Without an Accept header, or on a tie such as */*, text/html is preferred if offered
+func (odie *Odie) PreferredType(offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	header := odie.Request.Header.Get("Accept")
+	if len(strings.TrimSpace(header)) == 0 {
+		return preferDefault(offers)
+	}
+
+	ranges := parseAccept(header)
+	best, bestQ, bestSpecific := "", 0.0, -1
+	for _, offer := range offers {
+		q, specific := quality(ranges, offer)
+		if q <= 0 {
+			continue
+		}
+		better := q > bestQ ||
+			(q == bestQ && specific > bestSpecific) ||
+			(q == bestQ && specific == bestSpecific && offer == defaultType)
+		if better {
+			best, bestQ, bestSpecific = offer, q, specific
+		}
+	}
+	return best
+}
+
+func preferDefault(offers []string) string {
+	for _, offer := range offers {
+		if offer == defaultType {
+			return offer
+		}
+	}
+	return offers[0]
+}
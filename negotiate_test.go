@@ -0,0 +1,63 @@
+package goodie
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func acceptOdie(accept string) *Odie {
+	req := httptest.NewRequest("GET", "/", nil)
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept)
+	}
+	return &Odie{Request: req}
+}
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		accept string
+		mime   string
+		want   bool
+	}{
+		{"", "application/json", true},
+		{"application/json", "application/json", true},
+		{"Application/JSON", "application/json", true},
+		{"text/html", "application/json", false},
+		{"application/*", "application/json", true},
+		{"*/*", "image/png", true},
+		{"application/json;q=0", "application/json", false},
+		// the most specific range wins
+		{"application/*;q=0, application/json", "application/json", true},
+		{"*/*, application/json;q=0", "application/json", false},
+		{"text/html, bogus", "application/json", false},
+	}
+	for _, tt := range tests {
+		if got := acceptOdie(tt.accept).Accepts(tt.mime); got != tt.want {
+			t.Errorf("Accept %q Accepts(%s) = %t, want %t", tt.accept, tt.mime, got, tt.want)
+		}
+	}
+}
+
+func TestPreferredType(t *testing.T) {
+	tests := []struct {
+		accept string
+		offers []string
+		want   string
+	}{
+		{"", []string{"application/json", "text/html"}, "text/html"},
+		{"", []string{"application/json", "text/csv"}, "application/json"},
+		{"application/json", []string{"text/html", "application/json"}, "application/json"},
+		{"text/html;q=0.5, application/json;q=0.9", []string{"text/html", "application/json"}, "application/json"},
+		// on a tie the more specific range wins, then text/html
+		{"*/*, application/json", []string{"text/html", "application/json"}, "application/json"},
+		{"*/*", []string{"application/json", "text/html"}, "text/html"},
+		{"text/*;q=0.8, text/csv", []string{"text/html", "text/csv"}, "text/csv"},
+		{"image/png", []string{"text/html", "application/json"}, ""},
+		{"text/html", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := acceptOdie(tt.accept).PreferredType(tt.offers...); got != tt.want {
+			t.Errorf("Accept %q PreferredType(%v) = %q, want %q", tt.accept, tt.offers, got, tt.want)
+		}
+	}
+}
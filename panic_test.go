@@ -0,0 +1,86 @@
+package goodie
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.Register("init", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		panic("init")
+	}))
+	app.Register("display", func() Handler {
+		return &testPage{display: func(p *testPage) {
+			p.Body.Add(html.Text("partial page"))
+			panic("display")
+		}}
+	})
+	app.Register("committed", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		p.WriteValidationErrors(map[string]string{"name": "required"})
+		panic("after the response")
+	}))
+	app.Register("ok", textPage("ok"))
+
+	tests := []struct {
+		target string
+		status int
+		has    string
+		hasNot string
+	}{
+		{"/app/init", http.StatusInternalServerError, ServerError.Error(), ""},
+		{"/app/display", http.StatusInternalServerError, ServerError.Error(), "partial page"},
+		{"/app/committed", http.StatusUnprocessableEntity, "required", ServerError.Error()},
+		// the server keeps serving after a panic
+		{"/app/ok", http.StatusOK, "ok", ""},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, "GET", tt.target, "")
+		body := rec.Body.String()
+		if rec.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+		if !strings.Contains(body, tt.has) {
+			t.Errorf("GET %s = %q, want %q", tt.target, body, tt.has)
+		}
+		if len(tt.hasNot) > 0 && strings.Contains(body, tt.hasNot) {
+			t.Errorf("GET %s = %q, has %q", tt.target, body, tt.hasNot)
+		}
+	}
+}
+
+func TestPanicErrorRenderer(t *testing.T) {
+	s := newTestServer()
+	s.SetErrorRenderer(func(odie *Odie, err error) {
+		odie.Response.Write([]byte("custom: " + err.Error()))
+	})
+	app := s.NewApp("app")
+	app.Register("panic", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		panic("init")
+	}))
+
+	rec := serveTest(s, "GET", "/app/panic", "")
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "custom: "+ServerError.Error() {
+		t.Errorf("panic with an error renderer = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPanicAbortHandler(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.Register("abort", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+		}
+	}()
+	serveTest(s, "GET", "/app/abort", "")
+	t.Errorf("ErrAbortHandler was recovered")
+}
@@ -0,0 +1,32 @@
+package goodie
+
+import (
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+func TestParseFormKeepsDefaultQuery(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.AutoParseForm = false
+	app.SetDefaultQuery("sort", "name")
+	app.SetDefaultQuery("page", "1")
+
+	var sort, page, q string
+	app.Register("list", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		if err := p.ParseForm(); err != nil {
+			return nil, nil, err
+		}
+		sort, page, q = p.Url.GetQuery("sort"), p.Url.GetQuery("page"), p.Url.GetQuery("q")
+		return nil, []byte("ok"), nil
+	}))
+
+	rec := serveTest(s, "GET", "/app/list?page=3&q=x", "")
+	if rec.Code != 200 {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if sort != "name" || page != "3" || q != "x" {
+		t.Errorf("after ParseForm sort = %q, page = %q, q = %q, want name, 3, x", sort, page, q)
+	}
+}
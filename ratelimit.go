@@ -0,0 +1,117 @@
+package goodie
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// bucket is the token bucket of one client
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per client IP
+type rateLimiter struct {
+	perSecond float64
+	burst     float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+var rateLimitSweep = time.Minute
+
+// RateLimit is middleware that limits each client IP to perSecond requests, with bursts of up to burst requests.
+// A client over the limit gets a 429 Too Many Requests.  The client IP is proxy aware, see Server.SetTrustedProxies
+func RateLimit(perSecond int, burst int) Middleware {
+	if burst < 1 {
+		burst = 1
+	}
+	rl := &rateLimiter{
+		perSecond: float64(perSecond),
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key := req.RemoteAddr
+			if ip := requestServer(req).clientIP(req); ip != nil {
+				key = ip.String()
+			}
+
+			if !rl.allow(key, time.Now()) {
+				w.Header().Set("Retry-After", strconv.Itoa(rl.retryAfter()))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// allow will take a token from the client's bucket, refilling it for the time since the last request
+func (rl *rateLimiter) allow(key string, now time.Time) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.sweep(now)
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * rl.perSecond
+	if b.tokens > rl.burst {
+		b.tokens = rl.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sweep will remove buckets which have refilled, they are the same as a new bucket
+func (rl *rateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < rateLimitSweep {
+		return
+	}
+	rl.lastSweep = now
+
+	full := rl.fullAfter()
+	for key, b := range rl.buckets {
+		if now.Sub(b.last) > full {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// fullAfter is how long an empty bucket takes to refill
+func (rl *rateLimiter) fullAfter() time.Duration {
+	if rl.perSecond <= 0 {
+		return rateLimitSweep
+	}
+	return time.Duration(rl.burst / rl.perSecond * float64(time.Second))
+}
+
+// retryAfter is the seconds until a token is available
+func (rl *rateLimiter) retryAfter() int {
+	if rl.perSecond <= 0 {
+		return int(rateLimitSweep / time.Second)
+	}
+	secs := int(1 / rl.perSecond)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
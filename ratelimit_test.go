@@ -0,0 +1,93 @@
+package goodie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	start := time.Now()
+	rl := &rateLimiter{
+		perSecond: 2,
+		burst:     3,
+		buckets:   make(map[string]*bucket),
+		lastSweep: start,
+	}
+
+	tests := []struct {
+		key   string
+		after time.Duration
+		allow bool
+	}{
+		// a burst of 3, then empty
+		{"a", 0, true},
+		{"a", 0, true},
+		{"a", 0, true},
+		{"a", 0, false},
+		// each client has its own bucket
+		{"b", 0, true},
+		// 2 per second refills a token every 500ms
+		{"a", 400 * time.Millisecond, false},
+		{"a", 500 * time.Millisecond, true},
+		{"a", 500 * time.Millisecond, false},
+		// refilled up to the burst only
+		{"a", 10 * time.Second, true},
+		{"a", 10 * time.Second, true},
+		{"a", 10 * time.Second, true},
+		{"a", 10 * time.Second, false},
+	}
+	for i, tt := range tests {
+		if got := rl.allow(tt.key, start.Add(tt.after)); got != tt.allow {
+			t.Errorf("%d: allow(%s) after %s = %t, want %t", i, tt.key, tt.after, got, tt.allow)
+		}
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	start := time.Now()
+	rl := &rateLimiter{
+		perSecond: 1,
+		burst:     2,
+		buckets:   make(map[string]*bucket),
+		lastSweep: start,
+	}
+	rl.allow("old", start)
+	rl.allow("recent", start.Add(rateLimitSweep))
+
+	rl.allow("new", start.Add(rateLimitSweep+time.Second))
+	if _, ok := rl.buckets["old"]; ok {
+		t.Errorf("sweep kept a refilled bucket")
+	}
+	if _, ok := rl.buckets["recent"]; !ok {
+		t.Errorf("sweep removed a bucket which is not refilled")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := newTestServer()
+	s.Use(RateLimit(1, 2))
+	s.NewApp("app").Register("page", textPage("page"))
+
+	tests := []struct {
+		remote string
+		status int
+	}{
+		{"10.0.0.1:1000", http.StatusOK},
+		{"10.0.0.1:1001", http.StatusOK},
+		{"10.0.0.1:1002", http.StatusTooManyRequests},
+		{"10.0.0.2:1000", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/app/page", nil)
+		req.RemoteAddr = tt.remote
+		rec := serveRequest(s, req)
+		if rec.Code != tt.status {
+			t.Errorf("GET from %s status = %d, want %d", tt.remote, rec.Code, tt.status)
+		}
+		if tt.status == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "1" {
+			t.Errorf("GET from %s Retry-After = %q, want 1", tt.remote, rec.Header().Get("Retry-After"))
+		}
+	}
+}
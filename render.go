@@ -0,0 +1,81 @@
+package goodie
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// bufferWriter is a ResponseWriter which keeps the response in memory
+type bufferWriter struct {
+	header http.Header
+	status int
+	buf    bytes.Buffer
+}
+
+func (b *bufferWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.buf.Write(p)
+}
+
+func (b *bufferWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// RenderBytes will render handler as a GET of the current page, without any action, and return the HTML.
+// handler should be a new handler, as returned by a NewHandler.  An error from the handler is returned instead of the page
+func (odie *Odie) RenderBytes(handler Handler) ([]byte, error) {
+	if odie.app == nil || odie.Request == nil {
+		return nil, fmt.Errorf("RenderBytes: no request")
+	}
+
+	req := odie.Request.Clone(odie.Request.Context())
+	req.Method = http.MethodGet
+	q := req.URL.Query()
+	q.Del("action")
+	req.URL.RawQuery = q.Encode()
+	req.Form = nil
+	req.PostForm = nil
+	req.Body = http.NoBody
+	req.ContentLength = 0
+
+	w := &bufferWriter{header: make(http.Header)}
+	handler.render(odie.app, w, req, handler)
+
+	if err := handler.getOdie().renderErr; err != nil {
+		return nil, err
+	}
+	if w.status >= 400 {
+		return nil, fmt.Errorf("RenderBytes: %s", http.StatusText(w.status))
+	}
+	return w.buf.Bytes(), nil
+}
+
+// RenderToFile will render handler with RenderBytes and write the HTML to file, relative to the application's
+// base directory.  Nothing is written if the handler fails
+func (odie *Odie) RenderToFile(handler Handler, file string) error {
+	p, err := safePath(odie.Path, file)
+	if err != nil {
+		return err
+	}
+
+	data, err := odie.RenderBytes(handler)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0644)
+}
@@ -0,0 +1,209 @@
+package goodie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/debspencer/html"
+)
+
+func TestErrorPageContentLength(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.Register("missing", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, NotFound
+	}))
+	app.Register("busy", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, DbBusy
+	}))
+	app.Register("panic", func() Handler {
+		return &testPage{display: func(p *testPage) { panic("display") }}
+	})
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/app/missing", http.StatusNotFound},
+		{"/app/busy", http.StatusServiceUnavailable},
+		{"/app/panic", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, "GET", tt.target, "")
+		res := rec.Result()
+		if res.StatusCode != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, res.StatusCode, tt.status)
+		}
+		length := res.Header.Get("Content-Length")
+		if length != strconv.Itoa(rec.Body.Len()) {
+			t.Errorf("GET %s Content-Length = %q, want %d", tt.target, length, rec.Body.Len())
+		}
+	}
+}
+
+func TestErrorPageNotCached(t *testing.T) {
+	s := newTestServer()
+	s.SetCacheControl("public", time.Hour)
+	app := s.NewApp("app")
+	app.Register("status", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		p.SetETag(true)
+		p.SetStatus(http.StatusBadRequest)
+		return nil, nil, nil
+	}))
+	app.Register("missing", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		p.SetCacheControl("public", time.Hour)
+		return nil, nil, NotFound
+	}))
+	app.Register("ok", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		p.SetETag(true)
+		return nil, nil, nil
+	}))
+
+	tests := []struct {
+		target string
+		status int
+		cached bool
+	}{
+		{"/app/status", http.StatusBadRequest, false},
+		{"/app/missing", http.StatusNotFound, false},
+		{"/app/ok", http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		res := serveTest(s, "GET", tt.target, "").Result()
+		if res.StatusCode != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, res.StatusCode, tt.status)
+		}
+		cc := res.Header.Get("Cache-Control")
+		etag := res.Header.Get("ETag")
+		if tt.cached && (cc != "public, max-age=3600" || len(etag) == 0) {
+			t.Errorf("GET %s Cache-Control = %q, ETag = %q, want cached", tt.target, cc, etag)
+		}
+		if !tt.cached && (cc != defaultCacheControl || len(etag) > 0) {
+			t.Errorf("GET %s Cache-Control = %q, ETag = %q, want no-store", tt.target, cc, etag)
+		}
+	}
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.Register("page", textPage("page"))
+
+	rec := serveTest(s, "GET", "/app/missing", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("default not found status = %d, want 404", rec.Code)
+	}
+
+	s.SetNotFoundHandler(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no " + req.URL.Path))
+	})
+	rec = serveTest(s, "GET", "/app/missing", "")
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "no /app/missing" {
+		t.Errorf("SetNotFoundHandler = %d %q", rec.Code, rec.Body.String())
+	}
+
+	app.RegisterNotFound(func() Handler {
+		return &testPage{display: func(p *testPage) {
+			p.Body.Add(html.Text("lost " + p.Request.URL.Path))
+		}}
+	})
+	rec = serveTest(s, "GET", "/app/missing", "")
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "lost /app/missing") {
+		t.Errorf("RegisterNotFound = %d %q", rec.Code, rec.Body.String())
+	}
+	rec = serveTest(s, "GET", "/app/page", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "page" {
+		t.Errorf("GET /app/page with RegisterNotFound = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestErrorRenderer(t *testing.T) {
+	s := newTestServer()
+	var rendered error
+	s.SetErrorRenderer(func(odie *Odie, err error) {
+		rendered = err
+		odie.Response.Write([]byte("custom: " + err.Error()))
+	})
+	app := s.NewApp("app")
+	failing := func(err error) NewHandler {
+		return newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+			return nil, nil, err
+		})
+	}
+	wrapped := fmt.Errorf("Item 5: %w", NotFound)
+	other := errors.New("Other")
+	app.Register("wrapped", failing(wrapped))
+	app.Register("readonly", failing(ReadOnlyError))
+	app.Register("other", failing(other))
+
+	tests := []struct {
+		target string
+		status int
+		err    error
+	}{
+		{"/app/wrapped", http.StatusNotFound, wrapped},
+		{"/app/readonly", http.StatusForbidden, ReadOnlyError},
+		{"/app/other", http.StatusOK, other},
+	}
+	for _, tt := range tests {
+		rendered = nil
+		rec := serveTest(s, "GET", tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+		if rendered != tt.err || rec.Body.String() != "custom: "+tt.err.Error() {
+			t.Errorf("GET %s rendered %v as %q, want %v", tt.target, rendered, rec.Body.String(), tt.err)
+		}
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	status := func(init func(p *testPage) ([]*html.URL, []byte, error)) NewHandler {
+		return newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+			p.SetStatus(http.StatusCreated)
+			return init(p)
+		})
+	}
+	app.Register("page", status(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, nil
+	}))
+	app.Register("data", status(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, []byte("data"), nil
+	}))
+	app.Register("error", status(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, NotFound
+	}))
+	app.Register("json", status(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, p.WriteValidationErrors(map[string]string{"name": "required"})
+	}))
+	app.Register("redirect", status(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, nil, p.RedirectPath("/app/page", http.StatusSeeOther)
+	}))
+	app.Register("unset", textPage("unset"))
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/app/page", http.StatusCreated},
+		{"/app/data", http.StatusCreated},
+		{"/app/error", http.StatusNotFound},
+		{"/app/json", http.StatusUnprocessableEntity},
+		{"/app/redirect", http.StatusSeeOther},
+		{"/app/unset", http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, "GET", tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,652 @@
+package goodie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/debspencer/html"
+)
+
+type AppHandler struct {
+	handler  NewHandler
+	factory  HandlerFunc // used if handler is nil
+	app      *App
+	page     string // the registered page pattern, empty for a matcher
+	group    *Group // the group that registered the page, if any
+	internal bool   // only clients in allowed are permitted
+	allowed  []*net.IPNet
+}
+
+// route holds the handlers registered for a path, keyed by HTTP method.
+// The empty method matches any method that was not registered explicitly
+type route struct {
+	methods map[string]AppHandler
+}
+
+// handler returns the AppHandler for the method, falling back to GET for HEAD, then the any method handler
+func (r *route) handler(method string) (AppHandler, bool) {
+	if h, ok := r.methods[method]; ok {
+		return h, true
+	}
+	if method == http.MethodHead {
+		if h, ok := r.methods[http.MethodGet]; ok {
+			return h, true
+		}
+	}
+	h, ok := r.methods[""]
+	return h, ok
+}
+
+// allow returns the registered methods, for use in an Allow header.
+// HEAD is allowed with GET, and OPTIONS is always allowed as it is answered with the Allow header
+func (r *route) allow() string {
+	methods := make([]string, 0, len(r.methods)+2)
+	for m := range r.methods {
+		methods = append(methods, m)
+	}
+	if _, ok := r.methods[http.MethodGet]; ok {
+		if _, ok := r.methods[http.MethodHead]; !ok {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	if _, ok := r.methods[http.MethodOptions]; !ok {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+type paramsKey struct{}
+
+// routeKey holds the registered page pattern that matched a request
+type routeKey struct{}
+
+// notFoundKey marks a request being rendered by the not found page
+type notFoundKey struct{}
+
+// overrideKey holds the original method of a request with a _method override
+type overrideKey struct{}
+
+// methodOverrides are the methods a POST form can ask for with a _method field
+var methodOverrides = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// methodOverride returns the method of the _method field of a POST, or an empty string.
+// Only a url encoded form body is read, and only if readBody, so a multipart body, or the body
+// of an App without AutoParseForm, is not consumed.  The query string is never used, a link can not change the method
+func methodOverride(req *http.Request, readBody bool) (string, error) {
+	if req.Method != http.MethodPost || !readBody {
+		return "", nil
+	}
+	if ct, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); ct != "application/x-www-form-urlencoded" {
+		return "", nil
+	}
+	if err := req.ParseForm(); err != nil {
+		return "", bodyError(err)
+	}
+	method := req.PostForm.Get("_method")
+	method = strings.ToUpper(method)
+	if !methodOverrides[method] {
+		return "", nil
+	}
+	return method, nil
+}
+
+// overrideCandidate returns a handler that a POST with a _method override could reach, when POST is not registered
+func (r *route) overrideCandidate() (AppHandler, bool) {
+	for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		if h, ok := r.methods[method]; ok {
+			return h, true
+		}
+	}
+	return AppHandler{}, false
+}
+
+// splitPath returns the segments of path, a trailing slash is an empty last segment so /app/page/ does not match page
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+func isParamPattern(page string) bool {
+	for _, seg := range splitPath(page) {
+		if strings.HasPrefix(seg, ":") || isWildcard(seg) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrailingSlash is how a request path ending in a slash, such as /app/page/, is matched
+type TrailingSlash int
+
+const (
+	// TrailingSlashStrict, the default, matches the path as requested, so /app/page/ does not match page
+	TrailingSlashStrict TrailingSlash = iota
+	// TrailingSlashRedirect redirects a path not matching a page to the path without, or with, the slash when that matches
+	TrailingSlashRedirect
+	// TrailingSlashStrip serves a path not matching a page by the page without, or with, the slash, so /app/page/
+	// is served by page, and /app/dir by dir/
+	TrailingSlashStrip
+)
+
+// SetTrailingSlash sets how paths ending in a slash are matched to pages.  Static files are not affected,
+// a static directory is still redirected to the path with a slash
+func (s *Server) SetTrailingSlash(policy TrailingSlash) {
+	s.trailingSlash = policy
+}
+
+// trailingSlashPath will apply the trailing slash policy to req, matching the pages of t, returning true if a redirect was written.
+// A path matching a page as requested is kept, otherwise the path with its trailing slash removed, or added, is tried
+func (s *Server) trailingSlashPath(t *routes, w http.ResponseWriter, req *http.Request) (*http.Request, bool) {
+	if s.trailingSlash == TrailingSlashStrict || len(req.URL.Path) <= 1 || t.matches(req.URL.Path) {
+		return req, false
+	}
+
+	u := *req.URL
+	u.RawPath = ""
+	if strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimRight(u.Path, "/")
+		if len(u.Path) == 0 {
+			u.Path = "/"
+		}
+	} else {
+		u.Path += "/"
+	}
+	if !t.matches(u.Path) {
+		return req, false
+	}
+
+	if s.trailingSlash == TrailingSlashRedirect {
+		code := http.StatusPermanentRedirect // keeps the method and body
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(w, req, u.RequestURI(), code)
+		return req, true
+	}
+
+	req = req.WithContext(req.Context())
+	req.URL = &u
+	return req, false
+}
+
+// matches is true if path is a registered page of t.  A wildcard taking the trailing slash, as files/* does for
+// /files/a/, does not count, so the policy applies to wildcard pages too
+func (t *routes) matches(path string) bool {
+	if _, ok := t.handlers[path]; ok {
+		return true
+	}
+	r, params := t.matchParams(path)
+	if r == nil {
+		return false
+	}
+	if strings.HasSuffix(path, "/") {
+		for _, v := range params {
+			// only a wildcard value is empty or has a slash
+			if len(v) == 0 || strings.HasSuffix(v, "/") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type matchHandler struct {
+	AppHandler
+	match func(*http.Request) bool
+}
+
+// Register will register a handler for page, matching all HTTP methods
+func (a *App) Register(page string, h NewHandler) {
+	a.RegisterMethod("", page, h)
+}
+
+// HandlerFunc creates the handler for a request, an error renders a 500 Internal Server Error
+type HandlerFunc func(app *App) (Handler, error)
+
+// RegisterFunc will register a handler for page, created by fn, matching all HTTP methods.
+// Use it when creating the handler can fail, such as loading a template
+func (a *App) RegisterFunc(page string, fn HandlerFunc) {
+	a.register("", page, AppHandler{
+		factory: fn,
+		app:     a,
+	})
+}
+
+// newHandler will create the handler for a request
+func (ah AppHandler) newHandler() (Handler, error) {
+	if ah.handler != nil {
+		return ah.handler(), nil
+	}
+	return ah.factory(ah.app)
+}
+
+// RegisterMethod will register a handler for page that only responds to the given HTTP method.
+// Requests to a registered page with any other method will get a 405 Method Not Allowed
+// A page may contain named segments such as post/:id/comment/:cid, the values are available with Odie.Param.
+// A segment may have a constraint, such as :id|int, :code|[a-z]{3} or one added with AddParamConstraint, a value
+// not allowed by it does not match the page.
+// A page ending in a wildcard such as files/* or files/*path matches files and every page under it, the rest of the
+// path is Param("*") or Param("path"), empty for files itself
+func (a *App) RegisterMethod(method string, page string, h NewHandler) {
+	a.register(method, page, AppHandler{
+		handler: h,
+		app:     a,
+	})
+}
+
+// RegisterNamed will register a handler for page, matching all HTTP methods, with a name for RouteURL
+func (a *App) RegisterNamed(name string, page string, h NewHandler) {
+	a.Register(page, h)
+	if a.routeNames == nil {
+		a.routeNames = make(map[string]string)
+	}
+	a.routeNames[name] = a.pattern(page)
+}
+
+// pattern returns the server path registered for page, under the App's mount
+func (a *App) pattern(page string) string {
+	if len(page) > 0 && !strings.HasPrefix(page, "/") {
+		page = "/" + page
+	}
+	page = a.mount + page
+	if len(page) == 0 {
+		page = "/"
+	}
+	return page
+}
+
+// RouteURL returns a url to the route registered as name, pairs are keys and values filling in its named segments,
+// such as RouteURL("edit-item", "id", 42) for item/:id/edit.  Keys that are not segments are the query string.
+// Values are escaped.  A value for a named segment must be one non empty segment allowed by its constraint
+func (a *App) RouteURL(name string, pairs ...interface{}) (*html.URL, error) {
+	pattern, ok := a.routeNames[name]
+	if !ok {
+		return nil, fmt.Errorf("No route named %s in %s", name, a.name)
+	}
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("Route %s: odd number of key value pairs", name)
+	}
+
+	values := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		values[fmt.Sprint(pairs[i])] = fmt.Sprint(pairs[i+1])
+	}
+
+	var link strings.Builder
+	for _, seg := range splitPath(pattern) {
+		key, constraint := "", ""
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			key, constraint = splitParam(seg)
+		case isWildcard(seg):
+			key = wildcardName(seg)
+		}
+		if len(key) == 0 {
+			link.WriteString("/" + url.PathEscape(seg))
+			continue
+		}
+		v, ok := values[key]
+		if !ok {
+			return nil, fmt.Errorf("Route %s: missing %s", name, key)
+		}
+		delete(values, key)
+		if isWildcard(seg) {
+			link.WriteString("/" + escapePath(v))
+			continue
+		}
+
+		if len(v) == 0 || strings.Contains(v, "/") {
+			return nil, fmt.Errorf("Route %s: %s %q is not a single path segment", name, key, v)
+		}
+		if len(constraint) > 0 {
+			check, err := a.odie.paramConstraint(constraint)
+			if err != nil {
+				return nil, fmt.Errorf("Route %s: %w", name, err)
+			}
+			if !check(v) {
+				return nil, fmt.Errorf("Route %s: %s %q does not match %s", name, key, v, constraint)
+			}
+		}
+		link.WriteString("/" + url.PathEscape(v))
+	}
+	if link.Len() == 0 {
+		link.WriteString("/")
+	}
+
+	u := pathURL(link.String(), name)
+	query := make(url.Values, len(values))
+	for k, v := range values {
+		query.Set(k, v)
+	}
+	u.Query = escapeQuery(query)
+	return u, nil
+}
+
+// RouteURL returns a url to the route of the App registered as name, see App.RouteURL
+func (odie *Odie) RouteURL(name string, pairs ...interface{}) (*html.URL, error) {
+	return odie.app.RouteURL(name, pairs...)
+}
+
+// RegisterMethods will register a handler for page that responds to each of methods, other methods get a 405
+func (a *App) RegisterMethods(page string, h NewHandler, methods ...string) {
+	for _, method := range methods {
+		a.RegisterMethod(method, page, h)
+	}
+}
+
+// RegisterGet will register a handler for GET, and so HEAD, requests to page
+func (a *App) RegisterGet(page string, h NewHandler) {
+	a.RegisterMethod(http.MethodGet, page, h)
+}
+
+// RegisterPost will register a handler for POST requests to page
+func (a *App) RegisterPost(page string, h NewHandler) {
+	a.RegisterMethod(http.MethodPost, page, h)
+}
+
+// RegisterPut will register a handler for PUT requests to page, including a POST form with _method=PUT
+func (a *App) RegisterPut(page string, h NewHandler) {
+	a.RegisterMethod(http.MethodPut, page, h)
+}
+
+// RegisterPatch will register a handler for PATCH requests to page, including a POST form with _method=PATCH
+func (a *App) RegisterPatch(page string, h NewHandler) {
+	a.RegisterMethod(http.MethodPatch, page, h)
+}
+
+// RegisterDelete will register a handler for DELETE requests to page, including a POST form with _method=DELETE
+func (a *App) RegisterDelete(page string, h NewHandler) {
+	a.RegisterMethod(http.MethodDelete, page, h)
+}
+
+func (a *App) register(method string, page string, ah AppHandler) {
+	page = a.pattern(page)
+	method = strings.ToUpper(method)
+	a.odie.log().Infof("Register: %s %s", method, page)
+	ah.page = page
+
+	r := a.odie.route(a.routes(), page)
+	r.methods[method] = ah
+}
+
+// route will return the route for page in t, creating it if needed
+func (s *Server) route(t *routes, page string) *route {
+	if !isParamPattern(page) {
+		r, ok := t.handlers[page]
+		if !ok {
+			r = &route{
+				methods: make(map[string]AppHandler),
+			}
+			t.handlers[page] = r
+		}
+		return r
+	}
+
+	r, err := t.params.insert(splitPath(page), s.paramConstraint)
+	if err != nil {
+		// the route is not reachable, but registering on it is harmless
+		s.log().Errorf("Register %s: %s", page, err.Error())
+		return &route{
+			methods: make(map[string]AppHandler),
+		}
+	}
+	return r
+}
+
+// matchParams will find the best matching parametric route for path
+func (t *routes) matchParams(path string) (*route, map[string]string) {
+	return t.params.lookup(splitPath(path))
+}
+
+// RegisterMatch will register a handler that claims any request the matcher returns true for.
+// Matchers are tried in registration order before the registered paths, the path map is the fallback.
+// The matchers of an App created on a Host only see requests to that host
+func (a *App) RegisterMatch(matcher func(*http.Request) bool, h NewHandler) {
+	t := a.routes()
+	t.matchers = append(t.matchers, matchHandler{
+		AppHandler: AppHandler{
+			handler: h,
+			app:     a,
+		},
+		match: matcher,
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if len(s.healthPath) > 0 && req.URL.Path == s.healthPath {
+		s.health(w, req)
+		return
+	}
+	if s.metrics != nil && req.URL.Path == s.metrics.path {
+		s.metrics.serve(w, req)
+		return
+	}
+	if s.handleCORS(w, req) {
+		return
+	}
+
+	var h http.Handler = http.HandlerFunc(s.serve)
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		h = s.middleware[i](h)
+	}
+	s.logAccess(h, w, withServer(req, s))
+}
+
+// serve will find the handler for the request and render it
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) {
+	path := req.URL.Path
+	s.log().Debugf("Request: %s %s %s", req.Method, path, req.URL.RawQuery)
+	if st := s.matchStatic(path); st != nil {
+		st.serve(s, w, req)
+		return
+	}
+
+	if s.MaxBodyBytes > 0 && req.Body != nil {
+		req.Body = http.MaxBytesReader(w, req.Body, s.MaxBodyBytes)
+	}
+
+	t := s.hostRoutes(req)
+	req, handled := s.trailingSlashPath(t, w, req)
+	if handled {
+		return
+	}
+	path = req.URL.Path
+
+	for _, m := range t.matchers {
+		if m.match(req) {
+			s.dispatch(m.AppHandler, nil, w, req)
+			return
+		}
+	}
+
+	r, ok := t.handlers[path]
+	if !ok {
+		var params map[string]string
+		r, params = t.matchParams(path)
+		if r != nil {
+			ok = true
+			req = req.WithContext(context.WithValue(req.Context(), paramsKey{}, params))
+		}
+	}
+	if !ok {
+		if path == "/favicon.ico" && len(s.favicon) > 0 {
+			s.showFavicon(w)
+			return
+		}
+
+		s.notFound(w, req)
+		return
+	}
+
+	appHandler, ok := r.handler(req.Method)
+	if !ok && req.Method == http.MethodPost {
+		// dispatch decides if a _method override reaches a handler
+		appHandler, ok = r.overrideCandidate()
+	}
+	if !ok && req.Method == http.MethodOptions {
+		w.Header().Set("Allow", r.allow())
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if !ok {
+		s.methodNotAllowed(r, w, req)
+		return
+	}
+
+	s.dispatch(appHandler, r, w, req)
+}
+
+func (s *Server) methodNotAllowed(r *route, w http.ResponseWriter, req *http.Request) {
+	s.log().Infof("405 = '%s %s'", req.Method, req.URL.Path)
+	w.Header().Set("Allow", r.allow())
+	w.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+func (s *Server) notFound(w http.ResponseWriter, req *http.Request) {
+	s.log().Infof("404 = '%s'", req.URL.Path)
+	if s.notFoundPage != nil {
+		s.dispatch(*s.notFoundPage, nil, w, req.WithContext(context.WithValue(req.Context(), notFoundKey{}, true)))
+		return
+	}
+	if s.notFoundHandler != nil {
+		s.notFoundHandler(w, req)
+		return
+	}
+	w.WriteHeader(404)
+}
+
+// dispatch will render the request with appHandler.  r is the route it was found on, a POST to it may have
+// a _method override for another of its handlers
+func (s *Server) dispatch(appHandler AppHandler, r *route, w http.ResponseWriter, req *http.Request) {
+	if !appHandler.permitted(s, req) {
+		s.forbidden(w, req)
+		return
+	}
+	if s.MaxBodyBytes > 0 && req.ContentLength > s.MaxBodyBytes {
+		// rejected before the body is read, so a client sending Expect: 100-continue does not send it
+		s.log().Infof("413 = '%s' %d bytes", req.URL.Path, req.ContentLength)
+		renderErrorPage(appHandler.app, w, req, http.StatusRequestEntityTooLarge, RequestTooLarge)
+		return
+	}
+	if r != nil && req.Method == http.MethodPost {
+		var ok bool
+		appHandler, req, ok = s.override(appHandler, r, w, req)
+		if !ok {
+			return
+		}
+	}
+	handler, err := appHandler.newHandler()
+	if err != nil {
+		s.log().Errorf("handler for %s: %s", req.URL.Path, err.Error())
+		renderErrorPage(appHandler.app, w, req, http.StatusInternalServerError, ServerError)
+		return
+	}
+	if appHandler.app != nil {
+		setAccessHandler(req, fmt.Sprintf("%s %T", appHandler.app.name, handler), appHandler.page)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), routeKey{}, appHandler.page))
+	if appHandler.group != nil {
+		req = req.WithContext(context.WithValue(req.Context(), groupKey{}, appHandler.group))
+	}
+	if req.Method == http.MethodHead {
+		w = &headWriter{ResponseWriter: w}
+	}
+	defer recoverRender(handler, req)
+	handler.render(appHandler.app, w, req, handler)
+}
+
+func (s *Server) forbidden(w http.ResponseWriter, req *http.Request) {
+	s.log().Infof("403 = '%s' from %s", req.URL.Path, req.RemoteAddr)
+	w.WriteHeader(http.StatusForbidden)
+}
+
+// override will apply a _method override to a POST to r, returning the handler for the method.
+// It is false if a response has been written, such as a 405 when the method is not registered
+func (s *Server) override(appHandler AppHandler, r *route, w http.ResponseWriter, req *http.Request) (AppHandler, *http.Request, bool) {
+	readBody := appHandler.app == nil || appHandler.app.AutoParseForm
+	method, err := methodOverride(req, readBody)
+	if errors.Is(err, RequestTooLarge) {
+		s.log().Infof("413 = '%s'", req.URL.Path)
+		renderErrorPage(appHandler.app, w, req, http.StatusRequestEntityTooLarge, err)
+		return appHandler, req, false
+	}
+	if err != nil {
+		renderErrorPage(appHandler.app, w, req, http.StatusBadRequest, err)
+		return appHandler, req, false
+	}
+
+	if len(method) == 0 {
+		if _, ok := r.handler(http.MethodPost); !ok {
+			s.methodNotAllowed(r, w, req)
+			return appHandler, req, false
+		}
+		return appHandler, req, true
+	}
+
+	overridden, ok := r.handler(method)
+	if !ok {
+		s.methodNotAllowed(r, w, req)
+		return appHandler, req, false
+	}
+	if !overridden.permitted(s, req) {
+		s.forbidden(w, req)
+		return appHandler, req, false
+	}
+	req = req.WithContext(context.WithValue(req.Context(), overrideKey{}, req.Method))
+	req.Method = method
+	return overridden, req, true
+}
+
+// headWriter discards the body of a HEAD response, the headers including Content-Length are still written
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// renderErrorPage will render an error page when there is no handler to render it
+func renderErrorPage(app *App, w http.ResponseWriter, req *http.Request, status int, err error) {
+	odie := &Odie{
+		Request:  req,
+		Response: &pageWriter{ResponseWriter: w},
+		Doc:      newDocument(),
+		app:      app,
+	}
+	odie.errorStatus(status)
+	odie.RenderError(err)
+}
+
+// recoverRender will turn a panic while rendering into a 500 error page
+func recoverRender(handler Handler, req *http.Request) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if r == http.ErrAbortHandler {
+		panic(r)
+	}
+	requestLogger(req).Errorf("panic: %s %s: %v\n%s", req.Method, req.URL.Path, r, debug.Stack())
+
+	odie := handler.getOdie()
+	if odie.committed || odie.Response == nil {
+		return
+	}
+	odie.committed = true
+	odie.Doc = newDocument()
+	odie.errorStatus(http.StatusInternalServerError)
+	handler.RenderError(ServerError)
+}
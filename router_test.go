@@ -0,0 +1,357 @@
+package goodie
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+func TestTrailingSlashParams(t *testing.T) {
+	tests := []struct {
+		policy TrailingSlash
+		target string
+		status int
+		want   string // the body of a 200, or the Location of a redirect
+	}{
+		{TrailingSlashStrict, "/app/item/5", http.StatusOK, "item id=5"},
+		{TrailingSlashStrict, "/app/item/5/", http.StatusNotFound, ""},
+		{TrailingSlashStrict, "/app/item/", http.StatusNotFound, ""},
+		{TrailingSlashStrict, "/app/page/", http.StatusNotFound, ""},
+		{TrailingSlashStrict, "/app/dir/5/", http.StatusOK, "dir id=5"},
+		{TrailingSlashStrict, "/app/dir/5", http.StatusNotFound, ""},
+		{TrailingSlashStrict, "/app/slash/", http.StatusOK, "slash"},
+		{TrailingSlashStrict, "/app/slash", http.StatusNotFound, ""},
+		{TrailingSlashStrict, "/app/files/a/", http.StatusOK, "files path=a/"},
+
+		{TrailingSlashRedirect, "/app/item/5/", http.StatusMovedPermanently, "/app/item/5"},
+		{TrailingSlashRedirect, "/app/page", http.StatusOK, "page"},
+		{TrailingSlashRedirect, "/app/page/", http.StatusMovedPermanently, "/app/page"},
+		{TrailingSlashRedirect, "/app/files/a/", http.StatusMovedPermanently, "/app/files/a"},
+		{TrailingSlashRedirect, "/app/files/a", http.StatusOK, "files path=a"},
+		// pages registered with a slash are served as registered, and redirected to without it
+		{TrailingSlashRedirect, "/app/slash/", http.StatusOK, "slash"},
+		{TrailingSlashRedirect, "/app/slash", http.StatusMovedPermanently, "/app/slash/"},
+		{TrailingSlashRedirect, "/app/dir/5/", http.StatusOK, "dir id=5"},
+		{TrailingSlashRedirect, "/app/dir/5", http.StatusMovedPermanently, "/app/dir/5/"},
+		{TrailingSlashRedirect, "/app/nothing/", http.StatusNotFound, ""},
+
+		{TrailingSlashStrip, "/app/item/5/", http.StatusOK, "item id=5"},
+		{TrailingSlashStrip, "/app/page/", http.StatusOK, "page"},
+		{TrailingSlashStrip, "/app/files/a/", http.StatusOK, "files path=a"},
+		{TrailingSlashStrip, "/app/slash/", http.StatusOK, "slash"},
+		{TrailingSlashStrip, "/app/slash", http.StatusOK, "slash"},
+		{TrailingSlashStrip, "/app/dir/5/", http.StatusOK, "dir id=5"},
+		{TrailingSlashStrip, "/app/dir/5", http.StatusOK, "dir id=5"},
+		{TrailingSlashStrip, "/app/nothing/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		s := newTestServer()
+		s.SetTrailingSlash(tt.policy)
+		app := s.NewApp("app")
+		app.Register("page", paramsPage("page"))
+		app.Register("slash/", paramsPage("slash"))
+		app.Register("item/:id|int", paramsPage("item", "id"))
+		app.Register("dir/:id/", paramsPage("dir", "id"))
+		app.Register("files/*path", paramsPage("files", "path"))
+
+		rec := serveTest(s, "GET", tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("policy %d GET %s status = %d, want %d", tt.policy, tt.target, rec.Code, tt.status)
+			continue
+		}
+		switch tt.status {
+		case http.StatusOK:
+			if rec.Body.String() != tt.want {
+				t.Errorf("policy %d GET %s = %q, want %q", tt.policy, tt.target, rec.Body.String(), tt.want)
+			}
+		case http.StatusMovedPermanently:
+			if loc := rec.Header().Get("Location"); loc != tt.want {
+				t.Errorf("policy %d GET %s Location = %q, want %q", tt.policy, tt.target, loc, tt.want)
+			}
+		}
+	}
+}
+
+func TestWildcardEmptyRest(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.Register("files/*path", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, []byte("[" + p.Param("path") + "]"), nil
+	}))
+	app.Register("all/*", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, []byte("[" + p.Param("*") + "]"), nil
+	}))
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/app/files", http.StatusOK, "[]"},
+		{"/app/files/", http.StatusOK, "[]"},
+		{"/app/files/a", http.StatusOK, "[a]"},
+		{"/app/files/a/b", http.StatusOK, "[a/b]"},
+		{"/app/all", http.StatusOK, "[]"},
+		{"/app/filesx", http.StatusNotFound, ""},
+		{"/app", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, "GET", tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusOK && rec.Body.String() != tt.body {
+			t.Errorf("GET %s = %q, want %q", tt.target, rec.Body.String(), tt.body)
+		}
+	}
+}
+
+// paramsPage answers with the page name and the params of the request
+func paramsPage(name string, params ...string) NewHandler {
+	return newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		body := name
+		for _, param := range params {
+			body += " " + param + "=" + p.Param(param)
+		}
+		return nil, []byte(body), nil
+	})
+}
+
+func TestParamRoutes(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.Register("post/:id/comment/:cid", paramsPage("comment", "id", "cid"))
+	app.Register("post/:id", paramsPage("post", "id"))
+	app.Register("post/new", paramsPage("new"))
+	app.Register("post/:id/edit", paramsPage("edit", "id"))
+	app.Register("post/latest/edit", paramsPage("latest"))
+	app.Register("user/:id|int", paramsPage("user-int", "id"))
+	app.Register("user/:name", paramsPage("user-name", "name"))
+	app.Register("blog/:year/:slug", paramsPage("blog", "year", "slug"))
+	app.Register("blog/:year/archive", paramsPage("archive", "year"))
+	app.Register("docs/*path", paramsPage("docs", "path"))
+	app.Register("docs/:section/index", paramsPage("section", "section"))
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/app/post/5", http.StatusOK, "post id=5"},
+		{"/app/post/5/comment/9", http.StatusOK, "comment id=5 cid=9"},
+		{"/app/post/5/edit", http.StatusOK, "edit id=5"},
+
+		// a static segment wins the tie with a param
+		{"/app/post/new", http.StatusOK, "new"},
+		{"/app/post/latest/edit", http.StatusOK, "latest"},
+		{"/app/blog/2020/archive", http.StatusOK, "archive year=2020"},
+
+		// a static branch that does not reach a route backtracks to the param
+		{"/app/post/new/edit", http.StatusOK, "edit id=new"},
+		{"/app/post/latest", http.StatusOK, "post id=latest"},
+		{"/app/blog/2020/hello", http.StatusOK, "blog year=2020 slug=hello"},
+
+		// a constrained param is tried first
+		{"/app/user/42", http.StatusOK, "user-int id=42"},
+		{"/app/user/ann", http.StatusOK, "user-name name=ann"},
+
+		// a param wins over a wildcard, which takes what is left
+		{"/app/docs/intro/index", http.StatusOK, "section section=intro"},
+		{"/app/docs/intro/setup", http.StatusOK, "docs path=intro/setup"},
+
+		// trailing slashes and missing segments
+		{"/app/post/5/", http.StatusNotFound, ""},
+		{"/app/post/", http.StatusNotFound, ""},
+		{"/app/post", http.StatusNotFound, ""},
+		{"/app/post/5/comment/", http.StatusNotFound, ""},
+		{"/app/post//comment/9", http.StatusNotFound, ""},
+		{"/app/post/5/comment/9/x", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, "GET", tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusOK && rec.Body.String() != tt.body {
+			t.Errorf("GET %s = %q, want %q", tt.target, rec.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestMethodOverride(t *testing.T) {
+	s := csrfServer(true, false)
+	cookie, token := csrfForm(t, s)
+
+	item := func(app *App) {
+		app.RegisterGet("item", textPage("get"))
+		app.RegisterPost("item", textPage("post"))
+		app.RegisterPut("item", textPage("put"))
+		app.RegisterPatch("item", textPage("patch"))
+		app.RegisterDelete("item", textPage("delete"))
+		app.RegisterPost("upload", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+			if err := p.StreamUploads("", 1<<10, 1<<20, nil); err != nil {
+				return nil, nil, err
+			}
+			return nil, []byte("post name=" + p.Request.PostForm.Get("name")), nil
+		}))
+		app.RegisterDelete("upload", textPage("delete"))
+	}
+	item(s.NewApp("api"))
+	checked := s.NewApp("checked")
+	checked.CheckCSRF = true
+	item(checked)
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		multipart bool
+		status    int
+		want      string
+	}{
+		{"PUT", "POST", "/api/item", "_method=PUT", false, 200, "put"},
+		{"PATCH", "POST", "/api/item", "_method=PATCH", false, 200, "patch"},
+		{"DELETE", "POST", "/api/item", "_method=DELETE", false, 200, "delete"},
+		{"lower case", "POST", "/api/item", "_method=delete", false, 200, "delete"},
+
+		// only a POST body can override the method
+		{"query string", "POST", "/api/item?_method=DELETE", "", false, 200, "post"},
+		{"query string with a form", "POST", "/api/item?_method=DELETE", "name=Ann", false, 200, "post"},
+		{"GET", "GET", "/api/item?_method=DELETE", "", false, 200, "get"},
+		{"GET override", "POST", "/api/item", "_method=GET", false, 200, "post"},
+		{"disallowed method", "POST", "/api/item", "_method=TRACE", false, 200, "post"},
+		{"multipart body left unread", "POST", "/api/upload", "_method=DELETE", true, 200, "post name=Ann"},
+
+		// an override changes state, so it needs the CSRF token when the App checks it
+		{"without a CSRF token", "POST", "/checked/item", "_method=DELETE", false, 403, ""},
+		{"with a wrong CSRF token", "POST", "/checked/item", "_method=DELETE&_csrf=wrong", false, 403, ""},
+		{"with the CSRF token", "POST", "/checked/item", "_method=DELETE&_csrf=" + token, false, 200, "delete"},
+		{"POST without an override", "POST", "/checked/item", "", false, 200, "post"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.multipart {
+				var body bytes.Buffer
+				mw := multipart.NewWriter(&body)
+				for _, kv := range strings.Split(tt.body, "&") {
+					k, v, _ := strings.Cut(kv, "=")
+					mw.WriteField(k, v)
+				}
+				mw.WriteField("name", "Ann")
+				mw.Close()
+				req = httptest.NewRequest(tt.method, tt.target, &body)
+				req.Header.Set("Content-Type", mw.FormDataContentType())
+			} else {
+				req = httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+				if len(tt.body) > 0 {
+					req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				}
+			}
+			req.AddCookie(cookie)
+
+			rec := serveRequest(s, req)
+			if rec.Code != tt.status {
+				t.Fatalf("%s %s %q status = %d, want %d", tt.method, tt.target, tt.body, rec.Code, tt.status)
+			}
+			if tt.status == 200 && rec.Body.String() != tt.want {
+				t.Errorf("%s %s %q = %q, want %q", tt.method, tt.target, tt.body, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.RegisterGet("page", textPage("get"))
+	app.RegisterPost("page", textPage("post"))
+	app.RegisterPut("put", textPage("put"))
+	app.RegisterMethods("item/:id", paramsPage("item", "id"), http.MethodGet, http.MethodDelete)
+	app.RegisterMethod(http.MethodOptions, "options", textPage("options"))
+	app.RegisterGet("options", textPage("get"))
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		allow  string
+		body   string
+	}{
+		{"GET", "/app/page", http.StatusOK, "", "get"},
+		{"HEAD", "/app/page", http.StatusOK, "", ""},
+		{"DELETE", "/app/page", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS, POST", ""},
+		{"OPTIONS", "/app/page", http.StatusNoContent, "GET, HEAD, OPTIONS, POST", ""},
+		{"GET", "/app/put", http.StatusMethodNotAllowed, "OPTIONS, PUT", ""},
+		{"HEAD", "/app/put", http.StatusMethodNotAllowed, "OPTIONS, PUT", ""},
+		{"OPTIONS", "/app/put", http.StatusNoContent, "OPTIONS, PUT", ""},
+		{"PUT", "/app/item/5", http.StatusMethodNotAllowed, "DELETE, GET, HEAD, OPTIONS", ""},
+		{"OPTIONS", "/app/item/5", http.StatusNoContent, "DELETE, GET, HEAD, OPTIONS", ""},
+
+		// a registered OPTIONS handler answers it
+		{"OPTIONS", "/app/options", http.StatusOK, "", "options"},
+		{"POST", "/app/options", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS", ""},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, tt.method, tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+			continue
+		}
+		if got := rec.Header().Get("Allow"); got != tt.allow {
+			t.Errorf("%s %s Allow = %q, want %q", tt.method, tt.target, got, tt.allow)
+		}
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s %s = %q, want %q", tt.method, tt.target, rec.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestRegisterMethods(t *testing.T) {
+	s := newTestServer()
+	app := s.NewApp("app")
+	app.Register("mixed", textPage("any"))
+	app.RegisterPost("mixed", textPage("post"))
+	app.RegisterMethods("user/:id|int", paramsPage("user", "id"), http.MethodGet, http.MethodPut)
+	app.RegisterDelete("user/:id|int", paramsPage("delete", "id"))
+	app.RegisterGet("user/:name", paramsPage("name", "name"))
+
+	tests := []struct {
+		method string
+		target string
+		status int
+		body   string
+	}{
+		// the any method handler answers what has no handler of its own
+		{"GET", "/app/mixed", http.StatusOK, "any"},
+		{"POST", "/app/mixed", http.StatusOK, "post"},
+		{"DELETE", "/app/mixed", http.StatusOK, "any"},
+		{"OPTIONS", "/app/mixed", http.StatusOK, "any"},
+
+		// each param route has its own methods
+		{"GET", "/app/user/5", http.StatusOK, "user id=5"},
+		{"PUT", "/app/user/5", http.StatusOK, "user id=5"},
+		{"DELETE", "/app/user/5", http.StatusOK, "delete id=5"},
+		{"POST", "/app/user/5", http.StatusMethodNotAllowed, ""},
+		{"GET", "/app/user/ann", http.StatusOK, "name name=ann"},
+		{"DELETE", "/app/user/ann", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		rec := serveTest(s, tt.method, tt.target, "")
+		if rec.Code != tt.status {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusOK && rec.Body.String() != tt.body {
+			t.Errorf("%s %s = %q, want %q", tt.method, tt.target, rec.Body.String(), tt.body)
+		}
+	}
+}
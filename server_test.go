@@ -0,0 +1,73 @@
+package goodie
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/debspencer/html"
+)
+
+// freeAddr returns a local address nothing is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestRunContextShutdownTimeout(t *testing.T) {
+	s := newTestServer()
+	s.Addr = freeAddr(t)
+	s.ShutdownTimeout = 50 * time.Millisecond
+	s.WriteTimeout = 0
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	app := s.NewApp("app")
+	app.Register("slow", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		close(started)
+		<-release
+		return nil, nil, nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- s.RunContext(ctx)
+	}()
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			res, err := http.Get("http://" + s.Addr + "/app/slow")
+			if err == nil {
+				res.Body.Close()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not served")
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("RunContext = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after ShutdownTimeout")
+	}
+}
@@ -0,0 +1,130 @@
+package goodie
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	defaultSessionCookie = "goodie_session"
+	defaultSessionMaxAge = 24 * time.Hour
+
+	// maxCookieBytes is the size of a cookie browsers are required to keep, a larger one may be dropped
+	maxCookieBytes = 4096
+)
+
+// Session is a set of values kept in a signed cookie, so they survive across requests from the same browser.
+// The values can be read but not changed by the browser, so do not keep secrets in a session
+type Session struct {
+	odie   *Odie
+	values map[string]string
+}
+
+// SetSecret sets the secret used to sign session cookies.  They are signed with a key derived from it,
+// so a token made by NewToken with the same secret is never accepted as a session
+func (s *Server) SetSecret(secret []byte) {
+	s.secret = secret
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("goodie session"))
+	s.sessionKey = mac.Sum(nil)
+}
+
+// SetSessionCookie sets the name and max age of the session cookie, defaults to goodie_session and 24 hours
+func (s *Server) SetSessionCookie(name string, maxAge time.Duration) {
+	s.sessionCookie = name
+	s.sessionMaxAge = maxAge
+}
+
+// Session will return the session for the request, loaded from the session cookie.
+// A missing, tampered or expired cookie gives an empty session
+func (odie *Odie) Session() *Session {
+	if odie.session != nil {
+		return odie.session
+	}
+
+	odie.session = &Session{
+		odie:   odie,
+		values: make(map[string]string),
+	}
+	server := odie.server()
+	if server == nil || len(server.secret) == 0 {
+		return odie.session
+	}
+
+	cookie, err := odie.Request.Cookie(server.sessionCookie)
+	if err != nil {
+		return odie.session
+	}
+	values, err := VerifyToken(cookie.Value, server.sessionKey)
+	if err != nil {
+		return odie.session
+	}
+	odie.session.values = values
+	return odie.session
+}
+
+func (odie *Odie) server() *Server {
+	if odie.app == nil {
+		return nil
+	}
+	return odie.app.odie
+}
+
+// Get returns the session value for key, or an empty string
+func (s *Session) Get(key string) string {
+	return s.values[key]
+}
+
+// Set sets the session value for key, call Save to send it to the browser
+func (s *Session) Set(key string, value string) {
+	s.values[key] = value
+}
+
+// Delete removes key from the session, call Save to send it to the browser
+func (s *Session) Delete(key string) {
+	delete(s.values, key)
+}
+
+// Save will write the Set-Cookie header for the session, replacing one from an earlier Save.
+// It must be called before the response is written.
+// Returns an error if the cookie is over the 4096 bytes a browser keeps
+func (s *Session) Save() error {
+	server := s.odie.server()
+	if server == nil || len(server.secret) == 0 {
+		return fmt.Errorf("Session secret not configured")
+	}
+
+	cookie := &http.Cookie{
+		Name:     server.sessionCookie,
+		Value:    NewToken(s.values, server.sessionKey, server.sessionMaxAge),
+		Path:     "/",
+		MaxAge:   int(server.sessionMaxAge / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if n := len(cookie.String()); n > maxCookieBytes {
+		return fmt.Errorf("Session cookie of %d bytes is over %d", n, maxCookieBytes)
+	}
+	replaceCookie(s.odie.Response.Header(), cookie)
+	return nil
+}
+
+// replaceCookie will set cookie, removing any Set-Cookie for it from an earlier Save of the same response
+func replaceCookie(h http.Header, cookie *http.Cookie) {
+	prefix := cookie.Name + "="
+	var kept []string
+	for _, v := range h.Values("Set-Cookie") {
+		if !strings.HasPrefix(v, prefix) {
+			kept = append(kept, v)
+		}
+	}
+	h.Del("Set-Cookie")
+	for _, v := range kept {
+		h.Add("Set-Cookie", v)
+	}
+	h.Add("Set-Cookie", cookie.String())
+}
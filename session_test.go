@@ -0,0 +1,83 @@
+package goodie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/debspencer/html"
+)
+
+func TestSessionCookie(t *testing.T) {
+	secret := []byte("secret")
+	s := newTestServer()
+	s.SetSecret(secret)
+	app := s.NewApp("app")
+
+	var saveErr error
+	app.Register("set", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		session := p.Session()
+		session.Set("user", p.Request.FormValue("user"))
+		saveErr = session.Save()
+		return nil, nil, nil
+	}))
+	app.Register("get", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		return nil, []byte(p.Session().Get("user")), nil
+	}))
+
+	get := func(cookie *http.Cookie) string {
+		req := httptest.NewRequest("GET", "/app/get", nil)
+		req.AddCookie(cookie)
+		return serveRequest(s, req).Body.String()
+	}
+
+	rec := serveTest(s, "GET", "/app/set?user=ann", "")
+	if saveErr != nil {
+		t.Fatalf("Save: %s", saveErr)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Save set %d cookies, want 1", len(cookies))
+	}
+	if got := get(cookies[0]); got != "ann" {
+		t.Errorf("session user = %q, want ann", got)
+	}
+
+	// an app token signed with the server secret is not a session
+	token := NewToken(map[string]string{"user": "admin"}, secret, time.Hour)
+	if got := get(&http.Cookie{Name: defaultSessionCookie, Value: token}); len(got) > 0 {
+		t.Errorf("session from a NewToken token has user %q", got)
+	}
+	if _, err := VerifyToken(cookies[0].Value, secret); err == nil {
+		t.Errorf("VerifyToken accepted a session cookie")
+	}
+
+	serveTest(s, "GET", "/app/set?user="+strings.Repeat("x", maxCookieBytes), "")
+	if saveErr == nil {
+		t.Errorf("Save of a session over %d bytes did not fail", maxCookieBytes)
+	}
+}
+
+func TestSessionSaveTwice(t *testing.T) {
+	s := newTestServer()
+	s.SetSecret([]byte("secret"))
+	s.NewApp("app").Register("set", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+		http.SetCookie(p.Response, &http.Cookie{Name: "other", Value: "1"})
+		session := p.Session()
+		session.Set("a", "1")
+		session.Save()
+		session.Set("b", "2")
+		return nil, nil, session.Save()
+	}))
+
+	rec := serveTest(s, "GET", "/app/set", "")
+	var names []string
+	for _, c := range rec.Result().Cookies() {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, " "); got != "other "+defaultSessionCookie {
+		t.Errorf("cookies set = %q, want other and one session", got)
+	}
+}
@@ -0,0 +1,239 @@
+package goodie
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/debspencer/html"
+)
+
+// StaticOptions configures a static file route
+type StaticOptions struct {
+	// AllowDirListing will render a listing of a directory that does not have an index.html
+	AllowDirListing bool
+}
+
+type staticRoute struct {
+	StaticOptions
+	prefix string
+	fsys   fs.FS
+}
+
+// Static will serve the files under dir for requests beginning with urlPrefix, see RegisterStatic
+func (s *Server) Static(urlPrefix string, dir string) {
+	s.RegisterStatic(urlPrefix, dir, StaticOptions{})
+}
+
+// RegisterStatic will serve the files under dir for requests beginning with urlPrefix.
+// A relative dir is relative to the server home, or to the asset FS if one has been set.
+// The content type is set from the file extension, missing files and paths containing .. are not found
+func (s *Server) RegisterStatic(urlPrefix string, dir string, opts StaticOptions) {
+	fsys := os.DirFS(s.homePath(dir))
+	if s.assets != nil && !path.IsAbs(dir) {
+		sub, err := fs.Sub(s.assets, path.Clean(dir))
+		if err != nil {
+			s.log().Errorf("Static %s: %s", dir, err.Error())
+			return
+		}
+		fsys = sub
+	}
+	s.RegisterStaticFS(urlPrefix, fsys, opts)
+}
+
+// StaticFS will serve the files of fsys, such as an embed.FS, for requests beginning with urlPrefix
+func (s *Server) StaticFS(urlPrefix string, fsys fs.FS) {
+	s.RegisterStaticFS(urlPrefix, fsys, StaticOptions{})
+}
+
+// RegisterStaticFS is RegisterStatic for the files of fsys
+func (s *Server) RegisterStaticFS(urlPrefix string, fsys fs.FS, opts StaticOptions) {
+	s.statics = append(s.statics, &staticRoute{
+		StaticOptions: opts,
+		prefix:        "/" + strings.Trim(urlPrefix, "/"),
+		fsys:          fsys,
+	})
+}
+
+// SetAssetFS sets the filesystem, such as an embed.FS, that relative static dirs and favicon files are read from.
+// It must be set before they are registered.  Server.Path, used for database files, is not changed
+func (s *Server) SetAssetFS(fsys fs.FS) {
+	s.assets = fsys
+}
+
+// Static will serve the files under dir for requests beginning with urlPrefix under the App's mount
+func (a *App) Static(urlPrefix string, dir string) {
+	a.odie.RegisterStatic(a.mount+"/"+strings.Trim(urlPrefix, "/"), dir, StaticOptions{})
+}
+
+// matchStatic will return the static route serving path
+func (s *Server) matchStatic(path string) *staticRoute {
+	for _, st := range s.statics {
+		if path == st.prefix || strings.HasPrefix(path, st.prefix+"/") {
+			return st
+		}
+	}
+	return nil
+}
+
+func (st *staticRoute) serve(s *Server, w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	rel := strings.TrimPrefix(req.URL.Path, st.prefix)
+	file, err := fsPath(rel)
+	if err != nil {
+		s.notFound(w, req)
+		return
+	}
+
+	f, err := st.fsys.Open(file)
+	if err != nil {
+		s.notFound(w, req)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		s.notFound(w, req)
+		return
+	}
+
+	if fi.IsDir() {
+		// directories need a trailing slash so relative links work
+		if !strings.HasSuffix(req.URL.Path, "/") {
+			http.Redirect(w, req, req.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		index, err := st.fsys.Open(path.Join(file, "index.html"))
+		if err == nil {
+			defer index.Close()
+			if ifi, err := index.Stat(); err == nil && !ifi.IsDir() {
+				serveFile(w, req, ifi, index)
+				return
+			}
+		}
+
+		if !st.AllowDirListing {
+			s.notFound(w, req)
+			return
+		}
+		st.listDir(w, req, file)
+		return
+	}
+
+	serveFile(w, req, fi, f)
+}
+
+// fsPath will turn a url path into an fs.FS path, paths containing .. are an error
+func fsPath(rel string) (string, error) {
+	for _, elem := range strings.FieldsFunc(rel, isSlash) {
+		if elem == ".." {
+			return "", fs.ErrNotExist
+		}
+	}
+	name := strings.Trim(path.Clean("/"+rel), "/")
+	if len(name) == 0 {
+		name = "."
+	}
+	return name, nil
+}
+
+// serveFile will serve f with ServeContent, files which can not seek, which fs.FS files need not, are read into memory
+func serveFile(w http.ResponseWriter, req *http.Request, fi fs.FileInfo, f fs.File) {
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, req, fi.Name(), fi.ModTime(), rs)
+		return
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		http.Error(w, ServerError.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, req, fi.Name(), fi.ModTime(), bytes.NewReader(data))
+}
+
+// listDir will render a page with a link to each entry in the directory
+func (st *staticRoute) listDir(w http.ResponseWriter, req *http.Request, dir string) {
+	entries, err := fs.ReadDir(st.fsys, dir)
+	if err != nil {
+		http.Error(w, ServerError.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	doc := newDocument()
+	doc.Head().AddTitle(template.HTMLEscapeString(req.URL.Path))
+
+	body := doc.Body()
+	body.AddClassName("goodiebody")
+
+	header := html.Div()
+	header.AddClassName("goodieheader")
+	header.Add(html.Heading(3, urlStack(dirBreadcrumbs(req.URL.Path), defaultBreadcrumbSeparator)))
+	body.Add(header)
+
+	base := escapePath(req.URL.Path)
+	list := html.List(html.Unordered)
+	for _, fi := range entries {
+		name := fi.Name()
+		link := base + url.PathEscape(name)
+		if fi.IsDir() {
+			name += "/"
+			link += "/"
+		}
+		list.AddItem(pathURL(link, name))
+	}
+	body.Add(list)
+
+	w.Header().Set("Content-type", "text/html; charset=utf-8")
+	writeDocument(w, doc, 0)
+}
+
+// dirBreadcrumbs returns a url for each directory in path
+func dirBreadcrumbs(path string) []*html.URL {
+	var urls []*html.URL
+	link := "/"
+	for _, seg := range strings.FieldsFunc(path, isSlash) {
+		link += url.PathEscape(seg) + "/"
+		urls = append(urls, pathURL(link, seg))
+	}
+	return urls
+}
+
+// pathURL will create a url to an already escaped absolute path, with an escaped name.
+// html.NewLink can not be used as it unescapes the path
+func pathURL(link string, name string) *html.URL {
+	u := &html.URL{
+		Name:    name,
+		Element: html.Text(name),
+		Page:    strings.TrimPrefix(link, "/"),
+	}
+	if len(u.Page) == 0 {
+		u.Page = "/"
+	}
+	return u
+}
+
+// escapePath will escape each segment of path, keeping the slashes
+func escapePath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
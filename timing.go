@@ -0,0 +1,35 @@
+package goodie
+
+import (
+	"net/http"
+	"time"
+)
+
+// Timing is the time spent in each phase of rendering a page
+type Timing struct {
+	Parse   time.Duration // parsing the request form
+	Init    time.Duration
+	Action  time.Duration
+	Display time.Duration // Header, Display and Footer
+	Render  time.Duration // writing the document
+	Total   time.Duration
+}
+
+// TimingFunc is called after each page is rendered with the time spent in each phase
+type TimingFunc func(req *http.Request, t Timing)
+
+// lap will record the time since the last lap into phase
+func (odie *Odie) lap(phase *time.Duration) {
+	now := time.Now()
+	*phase = now.Sub(odie.lapStart)
+	odie.lapStart = now
+}
+
+func (odie *Odie) reportTiming(start time.Time) {
+	onTiming := odie.app.odie.OnTiming
+	if onTiming == nil {
+		return
+	}
+	odie.timing.Total = time.Since(start)
+	onTiming(odie.Request, odie.timing)
+}
@@ -0,0 +1,70 @@
+package goodie
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidToken = errors.New("Invalid Token")
+	ExpiredToken = errors.New("Expired Token")
+)
+
+type tokenData struct {
+	Payload map[string]string `json:"p"`
+	Expires int64             `json:"e"`
+}
+
+// NewToken will create a signed token containing payload, which is valid for ttl.
+// The token is url safe, so it can be used as a query string value in links
+func NewToken(payload map[string]string, secret []byte, ttl time.Duration) string {
+	data, _ := json.Marshal(tokenData{
+		Payload: payload,
+		Expires: time.Now().Add(ttl).Unix(),
+	})
+
+	body := base64.RawURLEncoding.EncodeToString(data)
+	return body + "." + base64.RawURLEncoding.EncodeToString(tokenSign(body, secret))
+}
+
+// VerifyToken will check the signature and expiry of a token created by NewToken and return its payload
+func VerifyToken(token string, secret []byte) (map[string]string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, InvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, tokenSign(parts[0], secret)) {
+		return nil, InvalidToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, InvalidToken
+	}
+
+	var td tokenData
+	if err := json.Unmarshal(data, &td); err != nil {
+		return nil, InvalidToken
+	}
+	if time.Now().Unix() > td.Expires {
+		return nil, ExpiredToken
+	}
+
+	if td.Payload == nil {
+		td.Payload = make(map[string]string)
+	}
+	return td.Payload, nil
+}
+
+func tokenSign(body string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
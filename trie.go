@@ -0,0 +1,211 @@
+package goodie
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// paramNode is a node of the trie of parametric routes, such as /blog/post/:id, with one level per path segment.
+// Lookup is by segment, so it does not slow down as more routes are registered
+type paramNode struct {
+	static   map[string]*paramNode
+	params   []*paramNode // any value for the segment allowed by each one's check, constrained params first
+	wildcard *paramNode   // any value for this and all of the following segments
+
+	constraint string            // of a param node, such as int for :id|int
+	check      func(string) bool // nil for a param without a constraint
+
+	route    *route   // set if a pattern ends at this node
+	segments []string // the pattern's segments, to name the param values
+}
+
+// ParamConstraint checks the value of a path segment such as :id|int, a value it rejects does not match the route
+type ParamConstraint func(value string) bool
+
+// paramConstraints are the built in constraints, any other constraint is a regular expression for the whole value
+var paramConstraints = map[string]ParamConstraint{
+	"int": func(v string) bool {
+		_, err := strconv.ParseInt(v, 10, 64)
+		return err == nil
+	},
+	"uint": func(v string) bool {
+		_, err := strconv.ParseUint(v, 10, 64)
+		return err == nil
+	},
+	"alpha": regexp.MustCompile(`^[A-Za-z]+$`).MatchString,
+	"alnum": regexp.MustCompile(`^[A-Za-z0-9]+$`).MatchString,
+	"uuid":  regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+}
+
+// AddParamConstraint will add a named constraint for path segments, such as :code|sku, to be checked by check.
+// It must be added before the routes using it are registered
+func (s *Server) AddParamConstraint(name string, check ParamConstraint) {
+	if s.paramConstraints == nil {
+		s.paramConstraints = make(map[string]ParamConstraint)
+	}
+	s.paramConstraints[name] = check
+}
+
+// paramConstraint returns the check for a constraint, one added to the server, built in, or a regular expression
+func (s *Server) paramConstraint(constraint string) (ParamConstraint, error) {
+	if check, ok := s.paramConstraints[constraint]; ok {
+		return check, nil
+	}
+	if check, ok := paramConstraints[constraint]; ok {
+		return check, nil
+	}
+	re, err := regexp.Compile("^(?:" + constraint + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("Invalid param constraint %s: %w", constraint, err)
+	}
+	return re.MatchString, nil
+}
+
+// splitParam returns the name and the constraint of a param segment, such as id and int for :id|int
+func splitParam(seg string) (string, string) {
+	name := strings.TrimPrefix(seg, ":")
+	if i := strings.IndexByte(name, '|'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+// insert will return the route for the pattern segments, creating it if needed.
+// constraint is called for the check of each constrained param
+func (n *paramNode) insert(segments []string, constraint func(string) (ParamConstraint, error)) (*route, error) {
+	node := n
+	for i, seg := range segments {
+		if isWildcard(seg) {
+			// the wildcard takes the rest of the path, so any segments after it are ignored
+			if node.wildcard == nil {
+				node.wildcard = &paramNode{}
+			}
+			node = node.wildcard
+			segments = segments[:i+1]
+			break
+		}
+		if strings.HasPrefix(seg, ":") {
+			next, err := node.param(seg, constraint)
+			if err != nil {
+				return nil, err
+			}
+			node = next
+			continue
+		}
+
+		if node.static == nil {
+			node.static = make(map[string]*paramNode)
+		}
+		next, ok := node.static[seg]
+		if !ok {
+			next = &paramNode{}
+			node.static[seg] = next
+		}
+		node = next
+	}
+
+	if node.route == nil {
+		node.route = &route{
+			methods: make(map[string]AppHandler),
+		}
+		node.segments = segments
+	}
+	return node.route, nil
+}
+
+// param returns the child for the param segment seg, params with the same constraint share a child
+func (n *paramNode) param(seg string, constraint func(string) (ParamConstraint, error)) (*paramNode, error) {
+	_, c := splitParam(seg)
+	for _, p := range n.params {
+		if p.constraint == c {
+			return p, nil
+		}
+	}
+
+	p := &paramNode{constraint: c}
+	if len(c) > 0 {
+		check, err := constraint(c)
+		if err != nil {
+			return nil, err
+		}
+		p.check = check
+		// constrained params are tried before a param taking any value
+		n.params = append([]*paramNode{p}, n.params...)
+		return p, nil
+	}
+	n.params = append(n.params, p)
+	return p, nil
+}
+
+// lookup will find the best route for segments, and the values of its named segments.
+// At each segment a static match is preferred over a param
+func (n *paramNode) lookup(segments []string) (*route, map[string]string) {
+	node := n.find(segments)
+	if node == nil {
+		return nil, nil
+	}
+
+	params := make(map[string]string)
+	for i, seg := range node.segments {
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			name, _ := splitParam(seg)
+			params[name] = segments[i]
+		case isWildcard(seg):
+			params[wildcardName(seg)] = strings.Join(segments[i:], "/")
+		}
+	}
+	return node.route, params
+}
+
+// find will walk the trie, backtracking to a param, then a wildcard, when a static branch does not reach a route
+func (n *paramNode) find(segments []string) *paramNode {
+	if len(segments) == 0 {
+		if n.route != nil {
+			return n
+		}
+		// files/* matches /files with an empty remainder
+		if n.wildcard != nil && n.wildcard.route != nil {
+			return n.wildcard
+		}
+		return nil
+	}
+
+	if next, ok := n.static[segments[0]]; ok {
+		if found := next.find(segments[1:]); found != nil {
+			return found
+		}
+	}
+	for _, p := range n.params {
+		if len(segments[0]) == 0 {
+			// a param is never empty, so /item/ is not /item/:id
+			break
+		}
+		if p.check != nil && !p.check(segments[0]) {
+			continue
+		}
+		if found := p.find(segments[1:]); found != nil {
+			return found
+		}
+	}
+	if n.wildcard != nil && n.wildcard.route != nil {
+		return n.wildcard
+	}
+	return nil
+}
+
+// isWildcard is true for a segment such as * or *path, which matches the rest of the path.
+// The rest may be empty, so files/*path matches /files and /files/ with an empty path
+func isWildcard(seg string) bool {
+	return strings.HasPrefix(seg, "*")
+}
+
+// wildcardName is the Param name of a wildcard segment, * for an unnamed wildcard
+func wildcardName(seg string) string {
+	if len(seg) == 1 {
+		return seg
+	}
+	return seg[1:]
+}
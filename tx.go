@@ -0,0 +1,99 @@
+package goodie
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/go-xorm/xorm"
+	"xorm.io/core"
+)
+
+var savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Tx is a database transaction, it has the same Db helpers as Odie
+type Tx struct {
+	dbOps
+	Session *xorm.Session
+}
+
+// Transaction will call f within a transaction.  If f returns nil the transaction is committed, otherwise it is rolled back
+func (odie *Odie) Transaction(f func(tx *Tx) error) error {
+	if odie.Orm == nil {
+		return fmt.Errorf("DB not configured")
+	}
+
+	ops := odie.ops()
+	if err := ops.probeConnection(); err != nil {
+		return err
+	}
+
+	session := odie.Orm.NewSession()
+	defer session.Close()
+	if ops.ctx != nil {
+		session.Context(ops.ctx)
+	}
+
+	if err := session.Begin(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			session.Rollback()
+			panic(r)
+		}
+	}()
+
+	tx := &Tx{
+		dbOps:   ops,
+		Session: session,
+	}
+	tx.db = session
+	tx.acquireTimeout = 0
+
+	if err := f(tx); err != nil {
+		session.Rollback()
+		return err
+	}
+	return session.Commit()
+}
+
+// Savepoint will create a named savepoint within the transaction of sess, which RollbackTo can roll back to.
+// An error is returned if the database does not support savepoints
+func (odie *Odie) Savepoint(sess *xorm.Session, name string) error {
+	sql, err := odie.savepointSQL("SAVEPOINT", "SAVE TRANSACTION", name)
+	if err != nil {
+		return err
+	}
+	_, err = sess.Exec(sql)
+	return err
+}
+
+// RollbackTo will roll back the transaction of sess to the savepoint name, the transaction stays open
+func (odie *Odie) RollbackTo(sess *xorm.Session, name string) error {
+	sql, err := odie.savepointSQL("ROLLBACK TO SAVEPOINT", "ROLLBACK TRANSACTION", name)
+	if err != nil {
+		return err
+	}
+	_, err = sess.Exec(sql)
+	return err
+}
+
+// savepointSQL returns the statement for the database, mssql has its own syntax
+func (odie *Odie) savepointSQL(statement string, mssql string, name string) (string, error) {
+	if odie.Orm == nil {
+		return "", fmt.Errorf("DB not configured")
+	}
+	if !savepointName.MatchString(name) {
+		return "", fmt.Errorf("Invalid savepoint name %q", name)
+	}
+
+	switch db := odie.Orm.Dialect().DBType(); db {
+	case core.SQLITE, core.MYSQL, core.POSTGRES, core.ORACLE:
+		return statement + " " + name, nil
+	case core.MSSQL:
+		return mssql + " " + name, nil
+	default:
+		return "", fmt.Errorf("Savepoints are not supported by %s", db)
+	}
+}
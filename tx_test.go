@@ -0,0 +1,83 @@
+package goodie
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	rollback := errors.New("Rollback")
+	tests := []struct {
+		name  string
+		f     func(tx *Tx) error
+		err   error
+		panic bool
+		want  string
+	}{
+		{"commit", func(tx *Tx) error {
+			tx.DbInsert(&testItem{Name: "a"})
+			return tx.DbInsert(&testItem{Name: "b"})
+		}, nil, false, "first a b"},
+		{"error", func(tx *Tx) error {
+			tx.DbInsert(&testItem{Name: "a"})
+			return rollback
+		}, rollback, false, "first"},
+		{"panic", func(tx *Tx) error {
+			tx.DbInsert(&testItem{Name: "a"})
+			panic("in transaction")
+		}, nil, true, "first"},
+		{"savepoint", func(tx *Tx) error {
+			tx.DbInsert(&testItem{Name: "a"})
+			odie := &Odie{}
+			odie.Orm = tx.engine
+			if err := odie.Savepoint(tx.Session, "before_b"); err != nil {
+				return err
+			}
+			tx.DbInsert(&testItem{Name: "b"})
+			if err := odie.RollbackTo(tx.Session, "before_b"); err != nil {
+				return err
+			}
+			return tx.DbInsert(&testItem{Name: "c"})
+		}, nil, false, "first a c"},
+	}
+	for _, tt := range tests {
+		odie := dbOdie(newTestDb(t))
+		if err := odie.DbInsert(&testItem{Name: "first"}); err != nil {
+			t.Fatalf("DbInsert: %s", err)
+		}
+
+		var err error
+		panicked := func() (panicked bool) {
+			defer func() {
+				panicked = recover() != nil
+			}()
+			err = odie.Transaction(tt.f)
+			return false
+		}()
+		if panicked != tt.panic {
+			t.Errorf("%s: panicked = %t, want %t", tt.name, panicked, tt.panic)
+		}
+		if err != tt.err {
+			t.Errorf("%s: Transaction = %v, want %v", tt.name, err, tt.err)
+		}
+		if got := itemNames(t, odie); got != tt.want {
+			t.Errorf("%s: items = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransactionReadOnly(t *testing.T) {
+	app := newTestDb(t)
+	app.ReadOnly = true
+	err := dbOdie(app).Transaction(func(tx *Tx) error {
+		return tx.DbInsert(&testItem{Name: "a"})
+	})
+	if !errors.Is(err, ReadOnlyError) {
+		t.Errorf("Transaction of a ReadOnly App = %v, want %v", err, ReadOnlyError)
+	}
+
+	err = (&Odie{}).Transaction(func(tx *Tx) error { return nil })
+	if err == nil {
+		t.Errorf("Transaction without a database did not fail")
+	}
+}
@@ -0,0 +1,181 @@
+package goodie
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxUploadNames is how many names createUpload tries before giving up
+const maxUploadNames = 100
+
+// UploadHandler is called for each file as it is received.  r must be read for the file to be saved.
+// filename is the path the file is written to, or the client's file name if there is no upload directory
+type UploadHandler func(field string, filename string, r io.Reader) error
+
+// limitReader returns an error once more than n bytes have been read
+type limitReader struct {
+	r    io.Reader
+	n    int64
+	err  error
+	over bool
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.over {
+		return 0, l.err
+	}
+	if l.n <= 0 {
+		// at the limit, only an error if there is more to read
+		var b [1]byte
+		n, err := l.r.Read(b[:])
+		if n > 0 {
+			l.over = true
+			return 0, l.err
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// StreamUploads will read a multipart form as it arrives, without buffering it in memory.
+// Each file is written to dir, relative to the application's base directory, while handler reads it.
+// An existing file is never replaced, a name already in use gets a numbered suffix, name-1.ext.
+// If dir is empty, files are only passed to handler, if handler is nil files are only written to dir.
+// Form values are added to the request's PostForm.  A file larger than maxPerFile, or a request
+// larger than maxTotal, is an error.  On error the partially written file is removed.
+// The App should have AutoParseForm turned off, so the body has not already been consumed.
+// A request with a Content-Length over maxTotal is rejected with RequestTooLarge before the body is read,
+// so a client sending Expect: 100-continue is never told to send it
+func (odie *Odie) StreamUploads(dir string, maxPerFile int64, maxTotal int64, handler UploadHandler) error {
+	if odie.Request.ContentLength > maxTotal {
+		return fmt.Errorf("Upload of %d bytes is over %d: %w", odie.Request.ContentLength, maxTotal, RequestTooLarge)
+	}
+
+	mr, err := odie.Request.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	if len(dir) > 0 {
+		dir, err = safePath(odie.Path, dir)
+		if err != nil {
+			return err
+		}
+	}
+	if odie.Request.PostForm == nil {
+		odie.Request.PostForm = make(url.Values)
+	}
+
+	var read int64
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return bodyError(err)
+		}
+
+		remaining := maxTotal - read
+		field := part.FormName()
+		filename := filepath.Base(part.FileName())
+
+		if len(part.FileName()) == 0 {
+			// a regular form value
+			lr := &limitReader{r: part, n: remaining, err: fmt.Errorf("Upload too large: %w", RequestTooLarge)}
+			value, err := io.ReadAll(lr)
+			part.Close()
+			if err != nil {
+				return bodyError(err)
+			}
+			read += int64(len(value))
+			odie.Request.PostForm.Add(field, string(value))
+			continue
+		}
+
+		limit := maxPerFile
+		if remaining < limit {
+			limit = remaining
+		}
+		n, err := odie.streamUpload(dir, field, filename, &limitReader{r: part, n: limit, err: fmt.Errorf("Upload too large: %s: %w", filename, RequestTooLarge)}, handler)
+		part.Close()
+		if err != nil {
+			return bodyError(err)
+		}
+		read += n
+	}
+}
+
+// streamUpload will write a single file to dir while passing it to handler, the file is removed on error
+func (odie *Odie) streamUpload(dir string, field string, filename string, r io.Reader, handler UploadHandler) (int64, error) {
+	counter := &countReader{r: r}
+	r = counter
+
+	var f *os.File
+	if len(dir) > 0 {
+		var err error
+		f, err = createUpload(dir, filename)
+		if err != nil {
+			return 0, err
+		}
+		filename = f.Name()
+		r = io.TeeReader(r, f)
+	}
+
+	err := func() error {
+		if handler != nil {
+			if err := handler(field, filename, r); err != nil {
+				return err
+			}
+		}
+		// read anything the handler did not, so the whole file is written
+		_, err := io.Copy(io.Discard, r)
+		return err
+	}()
+
+	if f != nil {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}
+	return counter.n, err
+}
+
+// createUpload will create a new file for filename in dir, adding a numbered suffix if the name is in use
+func createUpload(dir string, filename string) (*os.File, error) {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	name := filename
+	for i := 1; i <= maxUploadNames; i++ {
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !errors.Is(err, os.ErrExist) {
+			return f, err
+		}
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	return nil, fmt.Errorf("Upload name in use: %s", filename)
+}
+
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,114 @@
+package goodie
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+func TestStreamUploadsNames(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name     string
+		existing map[string]string
+		files    []string
+		fail     string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{"new file", nil, []string{"a.txt", "one"}, "",
+			map[string]string{"a.txt": "one"}, false},
+		{"existing file kept", map[string]string{"a.txt": "old"}, []string{"a.txt", "new"}, "",
+			map[string]string{"a.txt": "old", "a-1.txt": "new"}, false},
+		{"same name twice in a request", nil, []string{"a.txt", "one", "a.txt", "two"}, "",
+			map[string]string{"a.txt": "one", "a-1.txt": "two"}, false},
+		{"failed upload removed", nil, []string{"a.txt", "one"}, "a.txt",
+			map[string]string{}, true},
+		{"failed upload keeps the existing file", map[string]string{"a.txt": "old"}, []string{"a.txt", "new"}, "a-1.txt",
+			map[string]string{"a.txt": "old"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer()
+			home := t.TempDir()
+			s.SetHome(home)
+			dir := filepath.Join(home, "app", "up")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var gotErr error
+			app := s.NewApp("app")
+			app.AutoParseForm = false
+			app.Register("up", newPage(func(p *testPage) ([]*html.URL, []byte, error) {
+				gotErr = p.StreamUploads("up", 1<<10, 1<<20, func(field string, filename string, r io.Reader) error {
+					if filepath.Dir(filename) != dir {
+						t.Errorf("handler filename = %q, want a path in %q", filename, dir)
+					}
+					if filepath.Base(filename) == tt.fail {
+						io.ReadAll(r)
+						return boom
+					}
+					return nil
+				})
+				return nil, []byte("ok"), nil
+			}))
+
+			serveUpload(t, s, "/app/up", tt.files...)
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("StreamUploads error = %v, want error %v", gotErr, tt.wantErr)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, e := range entries {
+				b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[e.Name()] = string(b)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+			for name, content := range tt.want {
+				if got[name] != content {
+					t.Errorf("%s = %q, want %q", name, got[name], content)
+				}
+			}
+		})
+	}
+}
+
+// serveUpload will serve a multipart POST to target with a file part for each name and content pair
+func serveUpload(t *testing.T, s *Server, target string, files ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for i := 0; i+1 < len(files); i += 2 {
+		w, err := mw.CreateFormFile("file", files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, files[i+1])
+	}
+	mw.Close()
+	req := httptest.NewRequest("POST", target, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return serveRequest(s, req)
+}
@@ -0,0 +1,177 @@
+package goodie
+
+import (
+	"database/sql"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationErrors is the message for each field that failed Validate, keyed by the field's form key.
+// It can be passed to WriteValidationErrors
+type ValidationErrors map[string]string
+
+func (v ValidationErrors) Error() string {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	msgs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		msgs = append(msgs, key+": "+v[key])
+	}
+	return "Invalid " + strings.Join(msgs, ", ")
+}
+
+// LoadAndValidate will LoadFromQuery then Validate iface
+func (odie *Odie) LoadAndValidate(iface interface{}) error {
+	if err := odie.LoadFromQuery(iface); err != nil {
+		return err
+	}
+	return odie.Validate(iface)
+}
+
+// Validate will check the fields of the struct pointed to by iface against their tags, returning ValidationErrors
+// listing every field that failed.  Tags are `goodie:"required"`, `goodie:"min=1;max=100"` and `goodie:"email"`,
+// the rules may also be separated by a comma, as in `goodie:"key=user_id,required,min=1,max=100"`.
+// min and max are the value of numbers, and the length of strings, a bound which is not a number is an error.
+// Nested structs are validated with the keys the binder uses, such as address_city
+func (odie *Odie) Validate(iface interface{}) error {
+	rv := reflect.ValueOf(iface)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Validate: %T is not a pointer to a struct", iface)
+	}
+
+	errs := make(ValidationErrors)
+	if err := validateStruct(rv.Elem(), "", errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct will add the fields of rValue failing their rules to errs, their keys prefixed by prefix
+func validateStruct(rValue reflect.Value, prefix string, errs ValidationErrors) error {
+	for i := 0; i != rValue.NumField(); i++ {
+		fieldValue := rValue.Field(i)
+		field := rValue.Type().Field(i)
+		// as in loadStruct, the fields of an unexported embedded struct are promoted
+		if !fieldValue.CanInterface() && !(field.Anonymous && isNestedStruct(field.Type)) {
+			continue
+		}
+
+		tag := fieldTag(field)
+		if _, skip := tag["-"]; skip {
+			continue
+		}
+		if isNestedStruct(field.Type) {
+			nestedPrefix := prefix
+			if !field.Anonymous {
+				// the same prefix as loadStruct
+				name := underscoreKey(field.Name)
+				if k := tag["key"]; len(k) > 0 {
+					name = k
+				}
+				nestedPrefix = prefix + name + "_"
+			}
+			if err := validateStruct(fieldValue, nestedPrefix, errs); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := prefix + fieldKeys(field, tag)[0]
+		msg, err := validateField(fieldValue, tag)
+		if err != nil {
+			return fmt.Errorf("Validate %s: %w", key, err)
+		}
+		if len(msg) > 0 {
+			errs[key] = msg
+		}
+	}
+	return nil
+}
+
+// validateField returns the message for the first rule the field fails, or an empty string.
+// A min or max which is not a number is an error
+func validateField(fieldValue reflect.Value, tag map[string]string) (string, error) {
+	min, hasMin, err := validateBound(tag, "min")
+	if err != nil {
+		return "", err
+	}
+	max, hasMax, err := validateBound(tag, "max")
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := tag["required"]; ok && fieldValue.IsZero() {
+		return "is required", nil
+	}
+
+	if _, ok := tag["email"]; ok && fieldValue.Kind() == reflect.String {
+		s := fieldValue.String()
+		if len(s) > 0 {
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return "is not a valid email address", nil
+			}
+		}
+	}
+
+	n, ok := validateNumber(fieldValue)
+	if !ok {
+		return "", nil
+	}
+	what := "must be"
+	if fieldValue.Kind() == reflect.String {
+		what = "length must be"
+	}
+	if hasMin && n < min {
+		return fmt.Sprintf("%s at least %s", what, tag["min"]), nil
+	}
+	if hasMax && n > max {
+		return fmt.Sprintf("%s at most %s", what, tag["max"]), nil
+	}
+	return "", nil
+}
+
+// validateBound returns the number of the min or max rule, false if there is no rule
+func validateBound(tag map[string]string, rule string) (float64, bool, error) {
+	v, ok := tag[rule]
+	if !ok {
+		return 0, false, nil
+	}
+	bound, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("Invalid %s %q", rule, v)
+	}
+	return bound, true, nil
+}
+
+// validateNumber returns the number min and max are checked against, false for a type they do not apply to, or a null
+func validateNumber(fieldValue reflect.Value) (float64, bool) {
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fieldValue.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fieldValue.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fieldValue.Float(), true
+	case reflect.String:
+		return float64(len([]rune(fieldValue.String()))), true
+	}
+
+	switch v := fieldValue.Interface().(type) {
+	case sql.NullInt64:
+		return float64(v.Int64), v.Valid
+	case sql.NullFloat64:
+		return v.Float64, v.Valid
+	}
+	return 0, false
+}
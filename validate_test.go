@@ -0,0 +1,108 @@
+package goodie
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/debspencer/html"
+)
+
+type testAddress struct {
+	City string `goodie:"required"`
+	Zip  string `goodie:"min=5,max=5"`
+}
+
+type testAudit struct {
+	CreatedBy string `goodie:"required"`
+}
+
+type testSignup struct {
+	testAudit
+	Name    string `goodie:"required,min=2,max=10"`
+	Age     int    `goodie:"min=18;max=130"`
+	Email   string `goodie:"email"`
+	Address testAddress
+}
+
+func TestValidate(t *testing.T) {
+	valid := testSignup{testAudit: testAudit{CreatedBy: "root"}, Name: "Ann", Age: 30, Email: "ann@example.com", Address: testAddress{City: "Oslo", Zip: "01234"}}
+
+	tests := []struct {
+		name   string
+		modify func(v *testSignup)
+		want   ValidationErrors
+	}{
+		{"valid", func(v *testSignup) {}, nil},
+		{"required", func(v *testSignup) { v.Name = "" }, ValidationErrors{"name": "is required"}},
+		{"comma min", func(v *testSignup) { v.Name = "A" }, ValidationErrors{"name": "length must be at least 2"}},
+		{"comma max", func(v *testSignup) { v.Name = "Annabella Jones" }, ValidationErrors{"name": "length must be at most 10"}},
+		{"semicolon min", func(v *testSignup) { v.Age = 17 }, ValidationErrors{"age": "must be at least 18"}},
+		{"email", func(v *testSignup) { v.Email = "ann" }, ValidationErrors{"email": "is not a valid email address"}},
+		{"embedded", func(v *testSignup) { v.CreatedBy = "" }, ValidationErrors{"createdby": "is required"}},
+		{"nested", func(v *testSignup) { v.Address = testAddress{Zip: "1"} }, ValidationErrors{
+			"address_city": "is required",
+			"address_zip":  "length must be at least 5",
+		}},
+	}
+	for _, tt := range tests {
+		v := valid
+		tt.modify(&v)
+		err := (&Odie{}).Validate(&v)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: Validate = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		var got ValidationErrors
+		if !errors.As(err, &got) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Validate = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestValidateBadBound(t *testing.T) {
+	var v struct {
+		Name string `goodie:"min=two"`
+	}
+	err := (&Odie{}).Validate(&v)
+	var verrs ValidationErrors
+	if err == nil || errors.As(err, &verrs) {
+		t.Errorf("Validate with a bad bound = %v, want an error that is not ValidationErrors", err)
+	}
+}
+
+func TestValidateKeyTag(t *testing.T) {
+	type account struct {
+		UserID string `goodie:"key=user_id,required,min=2"`
+		Born   string `goodie:"layout=Jan 2, 2006;required"`
+		Status int    `goodie:"enum=active:1,inactive:0,required"`
+	}
+
+	tests := []struct {
+		query string
+		want  ValidationErrors
+	}{
+		{"user_id=42&born=x&status=active", nil},
+		{"born=x&status=active", ValidationErrors{"user_id": "is required"}},
+		{"user_id=4&born=x&status=active", ValidationErrors{"user_id": "length must be at least 2"}},
+		{"user_id=42&status=active", ValidationErrors{"born": "is required"}},
+		{"user_id=42&born=x&status=inactive", ValidationErrors{"status": "is required"}},
+	}
+	for _, tt := range tests {
+		odie := &Odie{Url: html.NewLink("/?" + tt.query)}
+		var v account
+		err := odie.LoadAndValidate(&v)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: LoadAndValidate = %v, want nil", tt.query, err)
+			}
+			continue
+		}
+		var got ValidationErrors
+		if !errors.As(err, &got) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: LoadAndValidate = %v, want %v", tt.query, err, tt.want)
+		}
+	}
+}